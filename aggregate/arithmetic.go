@@ -0,0 +1,144 @@
+package aggregate
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/flywingedai/dice/core"
+)
+
+//////////////
+// SUBTRACT //
+//////////////
+
+// The first roll minus every other roll
+type aggregate_Subtract struct{}
+
+func (a *aggregate_Subtract) Load(params map[string]interface{}) error {
+	return core.SetParams(a, params)
+}
+
+func (a *aggregate_Subtract) Aggregate(result *core.Result) {
+	for i, roll := range result.Results {
+		value := roll.Total
+		if i > 0 {
+			value = -value
+		}
+		result.Values = append(result.Values, value)
+		result.Total += value
+	}
+	result.Inherit(result.Results...)
+}
+
+func (a *aggregate_Subtract) Combine(parts []*core.Distribution) *core.Distribution {
+	combined := core.NewDistribution(map[int]float64{0: 1})
+	for i, part := range parts {
+		if i > 0 {
+			part = part.Map(func(value int) int { return -value })
+		}
+		combined = core.Convolve(combined, part)
+	}
+	return combined
+}
+
+///////////
+// SCALE //
+///////////
+
+// The sum of every roll, multiplied by a constant factor
+type aggregate_Scale struct {
+	Factor int `json:"factor"`
+}
+
+func (a *aggregate_Scale) Load(params map[string]interface{}) error {
+	return core.SetParams(a, params)
+}
+
+func (a *aggregate_Scale) Aggregate(result *core.Result) {
+	for _, roll := range result.Results {
+		result.Values = append(result.Values, roll.Total)
+		result.Total += roll.Total
+	}
+	result.Total *= a.Factor
+	result.Inherit(result.Results...)
+}
+
+func (a *aggregate_Scale) Combine(parts []*core.Distribution) *core.Distribution {
+	return (&aggregate_Sum{}).Combine(parts).Map(func(value int) int { return value * a.Factor })
+}
+
+////////////
+// DIVIDE //
+////////////
+
+// The sum of every roll, divided by a constant divisor and rounded
+type aggregate_Divide struct {
+	Divisor  int    `json:"divisor"`
+	Rounding string `json:"rounding"`
+}
+
+func (a *aggregate_Divide) Load(params map[string]interface{}) error {
+	if err := core.SetParams(a, params); err != nil {
+		return err
+	}
+	if a.Divisor == 0 {
+		return fmt.Errorf("%w: divisor must not be zero", core.ErrInvalidParams)
+	}
+	switch a.Rounding {
+	case core.ROUND_DOWN, core.ROUND_UP, core.ROUND_NEAREST:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown rounding %q", core.ErrInvalidParams, a.Rounding)
+}
+
+func (a *aggregate_Divide) Aggregate(result *core.Result) {
+	for _, roll := range result.Results {
+		result.Values = append(result.Values, roll.Total)
+		result.Total += roll.Total
+	}
+	result.Total = a.divide(result.Total)
+	result.Inherit(result.Results...)
+}
+
+func (a *aggregate_Divide) Combine(parts []*core.Distribution) *core.Distribution {
+	return (&aggregate_Sum{}).Combine(parts).Map(a.divide)
+}
+
+func (a *aggregate_Divide) divide(value int) int {
+	quotient := float64(value) / float64(a.Divisor)
+	switch a.Rounding {
+	case core.ROUND_UP:
+		return int(math.Ceil(quotient))
+	case core.ROUND_NEAREST:
+		return int(math.Round(quotient))
+	}
+	return int(math.Floor(quotient))
+}
+
+/////////////
+// PRODUCT //
+/////////////
+
+// Every roll multiplied together
+type aggregate_Product struct{}
+
+func (a *aggregate_Product) Load(params map[string]interface{}) error {
+	return core.SetParams(a, params)
+}
+
+func (a *aggregate_Product) Aggregate(result *core.Result) {
+	if len(result.Results) == 0 {
+		return
+	}
+	product := 1
+	for _, roll := range result.Results {
+		result.Values = append(result.Values, roll.Total)
+		product *= roll.Total
+	}
+	result.Total += product
+	result.Inherit(result.Results...)
+}
+
+func (a *aggregate_Product) Combine(parts []*core.Distribution) *core.Distribution {
+	return combinePairs(parts, func(x, y int) int { return x * y })
+}
@@ -0,0 +1,98 @@
+package aggregate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/flywingedai/dice/core"
+)
+
+////////////
+// CANCEL //
+////////////
+
+/*
+Sums the rolls like aggregate_Sum, then cancels opposing symbols against each
+other. Each entry of "pairs" maps a symbol to the symbol it cancels, such as
+"success" to "failure", and only whichever has more is left on the result,
+reduced by the other. If "total" names a symbol, the total is replaced by its
+net count, which is negative when its opposite is left over.
+*/
+type aggregate_Cancel struct {
+	Pairs map[string]string `json:"pairs"`
+	Total string            `json:"total"`
+}
+
+func (a *aggregate_Cancel) Load(params map[string]interface{}) error {
+	if err := core.SetParams(a, params); err != nil {
+		return err
+	}
+
+	// A symbol can only be cancelled by one other
+	seen := map[string]bool{}
+	for _, symbol := range a.sorted() {
+		opposite := a.Pairs[symbol]
+		if symbol == opposite {
+			return fmt.Errorf("%w: symbol %q cannot cancel itself", core.ErrInvalidParams, symbol)
+		}
+		if seen[symbol] || seen[opposite] {
+			return fmt.Errorf("%w: symbols %q and %q are in more than one pair", core.ErrInvalidParams, symbol, opposite)
+		}
+		seen[symbol] = true
+		seen[opposite] = true
+	}
+	return nil
+}
+
+func (a *aggregate_Cancel) Aggregate(result *core.Result) {
+	for _, roll := range result.Results {
+		result.Total += roll.Total
+		result.Values = append(result.Values, roll.Total)
+	}
+	result.Inherit(result.Results...)
+
+	for _, symbol := range a.sorted() {
+		opposite := a.Pairs[symbol]
+		net := result.Symbols[symbol] - result.Symbols[opposite]
+		delete(result.Symbols, symbol)
+		delete(result.Symbols, opposite)
+		switch {
+		case net > 0:
+			result.Symbols[symbol] = net
+		case net < 0:
+			result.Symbols[opposite] = -net
+		}
+	}
+
+	if a.Total != "" {
+		result.Total = a.net(result.Symbols, a.Total)
+	}
+}
+
+// Distributions only track totals, so the symbols can't be cancelled exactly
+func (a *aggregate_Cancel) ReadsSymbols() bool {
+	return true
+}
+
+// The first symbol of each pair in a fixed order
+func (a *aggregate_Cancel) sorted() []string {
+	symbols := make([]string, 0, len(a.Pairs))
+	for symbol := range a.Pairs {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// The count of a symbol less the count of the symbol it is paired with
+func (a *aggregate_Cancel) net(symbols map[string]int, symbol string) int {
+	for first, second := range a.Pairs {
+		switch symbol {
+		case first:
+			return symbols[first] - symbols[second]
+		case second:
+			return symbols[second] - symbols[first]
+		}
+	}
+	return symbols[symbol]
+}
@@ -0,0 +1,67 @@
+package aggregate
+
+import (
+	"slices"
+
+	"github.com/flywingedai/dice/core"
+)
+
+/////////////////////
+// COUNT SUCCESSES //
+/////////////////////
+
+/*
+Counts the rolls that match the "success" condition instead of summing them.
+Rolls in "botch" subtract a success, and rolls in "doubles" count as two
+successes. Botches are checked first, then doubles, then the condition.
+*/
+type aggregate_Count struct {
+	Success core.Condition `json:"success"`
+	Botch   []int          `json:"botch"`
+	Doubles []int          `json:"doubles"`
+}
+
+func (a *aggregate_Count) Load(params map[string]interface{}) error {
+	if err := core.SetParams(a, params); err != nil {
+		return err
+	}
+	return a.Success.Validate()
+}
+
+func (a *aggregate_Count) Aggregate(result *core.Result) {
+	for _, roll := range result.Results {
+		successes := a.successes(roll.Total)
+		if successes != 0 {
+			result.Values = append(result.Values, successes)
+			result.Total += successes
+			result.Inherit(roll)
+		}
+	}
+}
+
+// Each roll is counted on its own, so pools can add up their rolls' successes
+func (a *aggregate_Count) Additive() bool {
+	return true
+}
+
+// Each roll is counted on its own, so the parts can be counted and then added
+func (a *aggregate_Count) Combine(parts []*core.Distribution) *core.Distribution {
+	combined := core.NewDistribution(map[int]float64{0: 1})
+	for _, part := range parts {
+		combined = core.Convolve(combined, part.Map(a.successes))
+	}
+	return combined
+}
+
+// The number of successes a single roll is worth
+func (a *aggregate_Count) successes(total int) int {
+	switch {
+	case slices.Contains(a.Botch, total):
+		return -1
+	case slices.Contains(a.Doubles, total):
+		return 2
+	case a.Success.Matches(total):
+		return 1
+	}
+	return 0
+}
@@ -5,4 +5,31 @@ import "github.com/flywingedai/dice/core"
 func Initialize() {
 	core.AddAggregationType(core.AGGREGATE_SUM, func() core.Aggregation { return &aggregate_Sum{} })
 	core.AddAggregationType(core.AGGREGATE_SUM_INDEX, func() core.Aggregation { return &aggregate_SumIndex{} })
+	core.AddAggregationType(core.AGGREGATE_LAST, func() core.Aggregation { return &aggregate_Last{} })
+
+	// Keep and drop
+	core.AddAggregationType(core.AGGREGATE_KEEP_HIGHEST, func() core.Aggregation { return &aggregate_KeepHighest{} })
+	core.AddAggregationType(core.AGGREGATE_KEEP_LOWEST, func() core.Aggregation { return &aggregate_KeepLowest{} })
+	core.AddAggregationType(core.AGGREGATE_DROP_HIGHEST, func() core.Aggregation { return &aggregate_DropHighest{} })
+	core.AddAggregationType(core.AGGREGATE_DROP_LOWEST, func() core.Aggregation { return &aggregate_DropLowest{} })
+
+	// Dice pools
+	core.AddAggregationType(core.AGGREGATE_COUNT, func() core.Aggregation { return &aggregate_Count{} })
+
+	// Arithmetic
+	core.AddAggregationType(core.AGGREGATE_SUBTRACT, func() core.Aggregation { return &aggregate_Subtract{} })
+	core.AddAggregationType(core.AGGREGATE_SCALE, func() core.Aggregation { return &aggregate_Scale{} })
+	core.AddAggregationType(core.AGGREGATE_DIVIDE, func() core.Aggregation { return &aggregate_Divide{} })
+	core.AddAggregationType(core.AGGREGATE_PRODUCT, func() core.Aggregation { return &aggregate_Product{} })
+
+	// Selecting a single roll
+	core.AddAggregationType(core.AGGREGATE_MAX, func() core.Aggregation { return &aggregate_Max{} })
+	core.AddAggregationType(core.AGGREGATE_MIN, func() core.Aggregation { return &aggregate_Min{} })
+	core.AddAggregationType(core.AGGREGATE_MEDIAN, func() core.Aggregation { return &aggregate_Median{} })
+
+	// Opposed
+	core.AddAggregationType(core.AGGREGATE_OPPOSED, func() core.Aggregation { return &aggregate_Opposed{} })
+
+	// Symbols
+	core.AddAggregationType(core.AGGREGATE_CANCEL, func() core.Aggregation { return &aggregate_Cancel{} })
 }
@@ -0,0 +1,105 @@
+package aggregate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/flywingedai/dice/core"
+)
+
+//////////////////
+// KEEP HIGHEST //
+//////////////////
+
+type aggregate_KeepHighest struct {
+	Count int `json:"count"`
+}
+
+func (a *aggregate_KeepHighest) Load(params map[string]interface{}) error {
+	return loadCount(a, &a.Count, params)
+}
+
+func (a *aggregate_KeepHighest) Aggregate(result *core.Result) {
+	n := len(result.Results)
+	sumSorted(result, n-min(a.Count, n), n)
+}
+
+/////////////////
+// KEEP LOWEST //
+/////////////////
+
+type aggregate_KeepLowest struct {
+	Count int `json:"count"`
+}
+
+func (a *aggregate_KeepLowest) Load(params map[string]interface{}) error {
+	return loadCount(a, &a.Count, params)
+}
+
+func (a *aggregate_KeepLowest) Aggregate(result *core.Result) {
+	n := len(result.Results)
+	sumSorted(result, 0, min(a.Count, n))
+}
+
+//////////////////
+// DROP HIGHEST //
+//////////////////
+
+type aggregate_DropHighest struct {
+	Count int `json:"count"`
+}
+
+func (a *aggregate_DropHighest) Load(params map[string]interface{}) error {
+	return loadCount(a, &a.Count, params)
+}
+
+func (a *aggregate_DropHighest) Aggregate(result *core.Result) {
+	n := len(result.Results)
+	sumSorted(result, 0, n-min(a.Count, n))
+}
+
+/////////////////
+// DROP LOWEST //
+/////////////////
+
+type aggregate_DropLowest struct {
+	Count int `json:"count"`
+}
+
+func (a *aggregate_DropLowest) Load(params map[string]interface{}) error {
+	return loadCount(a, &a.Count, params)
+}
+
+func (a *aggregate_DropLowest) Aggregate(result *core.Result) {
+	n := len(result.Results)
+	sumSorted(result, min(a.Count, n), n)
+}
+
+/////////////
+// HELPERS //
+/////////////
+
+// Load params for the keep and drop aggregations, which all need a
+// non-negative count
+func loadCount[T any](a *T, count *int, params map[string]interface{}) error {
+	if err := core.SetParams(a, params); err != nil {
+		return err
+	}
+	if *count < 0 {
+		return fmt.Errorf("%w: count must not be negative, got %d", core.ErrInvalidParams, *count)
+	}
+	return nil
+}
+
+// Sort the rolls in ascending order and sum the ones in [from, to)
+func sumSorted(result *core.Result, from, to int) {
+	sort.SliceStable(result.Results, func(i, j int) bool {
+		return result.Results[i].Total < result.Results[j].Total
+	})
+
+	for _, roll := range result.Results[from:to] {
+		result.Values = append(result.Values, roll.Total)
+		result.Total += roll.Total
+		result.Inherit(roll)
+	}
+}
@@ -0,0 +1,25 @@
+package aggregate
+
+import "github.com/flywingedai/dice/core"
+
+//////////
+// LAST //
+//////////
+
+// Only the last roll counts towards the total, such as the kept roll after a
+// reroll.
+type aggregate_Last struct{}
+
+func (a *aggregate_Last) Load(params map[string]interface{}) error {
+	return core.SetParams(a, params)
+}
+
+func (a *aggregate_Last) Aggregate(result *core.Result) {
+	if len(result.Results) == 0 {
+		return
+	}
+	last := result.Results[len(result.Results)-1]
+	result.Values = append(result.Values, last.Total)
+	result.Total += last.Total
+	result.Inherit(last)
+}
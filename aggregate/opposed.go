@@ -0,0 +1,73 @@
+package aggregate
+
+import (
+	"fmt"
+
+	"github.com/flywingedai/dice/core"
+)
+
+/////////////
+// OPPOSED //
+/////////////
+
+/*
+Compares the first roll, the attacker, against the second, the defender. In
+OPPOSED_MARGIN mode the total is the attacker's margin of success, which is
+negative when the defender wins. In OPPOSED_OUTCOME mode the total is 1 for an
+attacker win, -1 for a defender win and 0 for a tie, and "ties" can instead
+award ties to TIES_ATTACKER or TIES_DEFENDER.
+*/
+type aggregate_Opposed struct {
+	Mode string `json:"mode"`
+	Ties string `json:"ties"`
+}
+
+func (a *aggregate_Opposed) Load(params map[string]interface{}) error {
+	if err := core.SetParams(a, params); err != nil {
+		return err
+	}
+	switch a.Mode {
+	case core.OPPOSED_MARGIN, core.OPPOSED_OUTCOME:
+	default:
+		return fmt.Errorf("%w: unknown mode %q", core.ErrInvalidParams, a.Mode)
+	}
+	switch a.Ties {
+	case core.TIES_TIE, core.TIES_ATTACKER, core.TIES_DEFENDER:
+	default:
+		return fmt.Errorf("%w: unknown ties %q", core.ErrInvalidParams, a.Ties)
+	}
+	return nil
+}
+
+func (a *aggregate_Opposed) Aggregate(result *core.Result) {
+	if len(result.Results) != 2 {
+		return
+	}
+	value := a.resolve(result.Results[0].Total - result.Results[1].Total)
+	result.Values = append(result.Values, value)
+	result.Total += value
+	result.Inherit(result.Results...)
+}
+
+func (a *aggregate_Opposed) Combine(parts []*core.Distribution) *core.Distribution {
+	return (&aggregate_Subtract{}).Combine(parts).Map(a.resolve)
+}
+
+// Turn the attacker's margin into the total for the mode
+func (a *aggregate_Opposed) resolve(margin int) int {
+	if a.Mode == core.OPPOSED_MARGIN {
+		return margin
+	}
+
+	switch {
+	case margin > 0:
+		return 1
+	case margin < 0:
+		return -1
+	case a.Ties == core.TIES_ATTACKER:
+		return 1
+	case a.Ties == core.TIES_DEFENDER:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,129 @@
+package aggregate
+
+import (
+	"sort"
+
+	"github.com/flywingedai/dice/core"
+)
+
+/////////
+// MAX //
+/////////
+
+// Only the highest roll counts towards the total
+type aggregate_Max struct{}
+
+func (a *aggregate_Max) Load(params map[string]interface{}) error {
+	return core.SetParams(a, params)
+}
+
+func (a *aggregate_Max) Aggregate(result *core.Result) {
+	if len(result.Results) == 0 {
+		return
+	}
+	highest := result.Results[0]
+	for _, roll := range result.Results[1:] {
+		if roll.Total > highest.Total {
+			highest = roll
+		}
+	}
+	result.Values = append(result.Values, highest.Total)
+	result.Total += highest.Total
+	result.Inherit(highest)
+}
+
+func (a *aggregate_Max) Combine(parts []*core.Distribution) *core.Distribution {
+	return combinePairs(parts, func(x, y int) int { return max(x, y) })
+}
+
+/////////
+// MIN //
+/////////
+
+// Only the lowest roll counts towards the total
+type aggregate_Min struct{}
+
+func (a *aggregate_Min) Load(params map[string]interface{}) error {
+	return core.SetParams(a, params)
+}
+
+func (a *aggregate_Min) Aggregate(result *core.Result) {
+	if len(result.Results) == 0 {
+		return
+	}
+	lowest := result.Results[0]
+	for _, roll := range result.Results[1:] {
+		if roll.Total < lowest.Total {
+			lowest = roll
+		}
+	}
+	result.Values = append(result.Values, lowest.Total)
+	result.Total += lowest.Total
+	result.Inherit(lowest)
+}
+
+func (a *aggregate_Min) Combine(parts []*core.Distribution) *core.Distribution {
+	return combinePairs(parts, func(x, y int) int { return min(x, y) })
+}
+
+////////////
+// MEDIAN //
+////////////
+
+/*
+Only the median roll counts towards the total. With an even number of rolls,
+the two middle rolls are averaged and rounded down. The rolls are sorted in
+ascending order in the Result's "Results".
+*/
+type aggregate_Median struct{}
+
+func (a *aggregate_Median) Load(params map[string]interface{}) error {
+	return core.SetParams(a, params)
+}
+
+func (a *aggregate_Median) Aggregate(result *core.Result) {
+	n := len(result.Results)
+	if n == 0 {
+		return
+	}
+
+	sort.SliceStable(result.Results, func(i, j int) bool {
+		return result.Results[i].Total < result.Results[j].Total
+	})
+
+	median := result.Results[n/2].Total
+	result.Inherit(result.Results[n/2])
+	if n%2 == 0 {
+		sum := result.Results[n/2-1].Total + median
+		median = sum / 2
+		if sum < 0 && sum%2 != 0 {
+			median--
+		}
+		result.Inherit(result.Results[n/2-1])
+	}
+	result.Values = append(result.Values, median)
+	result.Total += median
+}
+
+/////////////
+// HELPERS //
+/////////////
+
+// Fold independent parts together with a function of two totals
+func combinePairs(parts []*core.Distribution, f func(int, int) int) *core.Distribution {
+	if len(parts) == 0 {
+		return core.NewDistribution(map[int]float64{0: 1})
+	}
+
+	combined := parts[0]
+	for _, part := range parts[1:] {
+		probabilities := map[int]float64{}
+		for x, px := range combined.Probabilities {
+			for y, py := range part.Probabilities {
+				probabilities[f(x, y)] += px * py
+			}
+		}
+		combined = core.NewDistribution(probabilities)
+	}
+	return combined
+}
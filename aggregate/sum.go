@@ -1,6 +1,7 @@
 package aggregate
 
 import (
+	"fmt"
 	"sort"
 
 	"github.com/flywingedai/dice/core"
@@ -12,8 +13,8 @@ import (
 
 type aggregate_Sum struct{}
 
-func (a *aggregate_Sum) Load(params map[string]interface{}) {
-	core.SetParams(a, params)
+func (a *aggregate_Sum) Load(params map[string]interface{}) error {
+	return core.SetParams(a, params)
 }
 
 func (a *aggregate_Sum) Aggregate(result *core.Result) {
@@ -21,6 +22,19 @@ func (a *aggregate_Sum) Aggregate(result *core.Result) {
 		result.Total += roll.Total
 		result.Values = append(result.Values, roll.Total)
 	}
+	result.Inherit(result.Results...)
+}
+
+func (a *aggregate_Sum) Additive() bool {
+	return true
+}
+
+func (a *aggregate_Sum) Combine(parts []*core.Distribution) *core.Distribution {
+	combined := core.NewDistribution(map[int]float64{0: 1})
+	for _, part := range parts {
+		combined = core.Convolve(combined, part)
+	}
+	return combined
 }
 
 //////////////////////////
@@ -31,8 +45,18 @@ type aggregate_SumIndex struct {
 	Indices []int `json:"indices"`
 }
 
-func (a *aggregate_SumIndex) Load(params map[string]interface{}) {
-	core.SetParams(a, params)
+func (a *aggregate_SumIndex) Load(params map[string]interface{}) error {
+	return core.SetParams(a, params)
+}
+
+// Every index must pick out one of the rolls
+func (a *aggregate_SumIndex) ValidateResults(count int) error {
+	for _, index := range a.Indices {
+		if index >= count || index < -count {
+			return fmt.Errorf("%w: index %d is out of range for %d rolls", core.ErrInvalidParams, index, count)
+		}
+	}
+	return nil
 }
 
 func (a *aggregate_SumIndex) Aggregate(result *core.Result) {
@@ -49,6 +73,7 @@ func (a *aggregate_SumIndex) Aggregate(result *core.Result) {
 		}
 		result.Values = append(result.Values, result.Results[index].Total)
 		result.Total += result.Results[index].Total
+		result.Inherit(result.Results[index])
 	}
 
 }
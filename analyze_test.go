@@ -0,0 +1,36 @@
+package dice
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/flywingedai/dice/core"
+)
+
+func TestAnalyzeNSeeded(t *testing.T) {
+	a := New(6).Multiple(2).AnalyzeNSeeded(1000, 2, 42)
+	b := New(6).Multiple(2).AnalyzeNSeeded(1000, 2, 42)
+	if a.N != 1000 || a.Mean != b.Mean {
+		t.Errorf("N = %d, means %v and %v, want 1000 rolls with the same mean", a.N, a.Mean, b.Mean)
+	}
+}
+
+func TestAnalyzeNotPositive(t *testing.T) {
+	analyses := map[string]func(){
+		"AnalyzeN(0)":        func() { New(6).AnalyzeN(0, 1) },
+		"AnalyzeN(-1)":       func() { New(6).AnalyzeN(-1, 2) },
+		"AnalyzeNSeeded(0)":  func() { New(6).AnalyzeNSeeded(0, 1, 42) },
+		"AnalyzeNSeeded(-5)": func() { New(6).AnalyzeNSeeded(-5, 1, 42) },
+	}
+	for name, analyze := range analyses {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, core.ErrInvalidParams) {
+					t.Errorf("%s: recovered %v, want ErrInvalidParams", name, err)
+				}
+			}()
+			analyze()
+		}()
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/flywingedai/dice/core"
+	"github.com/flywingedai/dice/render"
+)
+
+// The number of rolls analyze and compare sample by default
+const defaultN = 1_000_000
+
+// dice analyze <definition>... [-n N] [--threads T] [--seed S] [--format F] [--svg FILE] [--ascii]
+func analyzeCommand(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("analyze", stderr)
+	sampling := addSamplingFlags(fs, defaultN)
+	format := "text"
+	svg := ""
+	ascii := false
+	fs.StringVar(&format, "format", "text", "output format: text, csv, markdown or json")
+	fs.StringVar(&svg, "svg", "", "also write an SVG chart to this file")
+	fs.BoolVar(&ascii, "ascii", false, "draw the bar chart with plain ASCII")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("%w: expected at least 1 definition", errUsage)
+	}
+	switch format {
+	case "text", "csv", "markdown", "json":
+	default:
+		return fmt.Errorf("%w: unknown format %q", errUsage, format)
+	}
+	definitions, err := loadDefinitions(positional, len(positional))
+	if err != nil {
+		return err
+	}
+
+	table := &core.Table{Labels: positional}
+	for _, d := range definitions {
+		A, err := d.AnalyzeContext(context.Background(), core.AnalyzeOptions{
+			N:       int(sampling.n),
+			Threads: int(sampling.threads),
+			Seed:    sampling.seed.seed,
+		})
+		if err != nil {
+			return err
+		}
+		table.Analyses = append(table.Analyses, A)
+	}
+
+	if err := writeAnalyses(stdout, table, format, ascii); err != nil {
+		return err
+	}
+
+	if svg != "" {
+		series := make([]render.Series, len(positional))
+		for i, label := range positional {
+			series[i] = render.Series{Label: label, Analysis: table.Analyses[i]}
+		}
+		title := ""
+		if len(positional) == 1 {
+			title = positional[0]
+		}
+		return writeSVG(svg, series, title)
+	}
+	return nil
+}
+
+/*
+Write the analyses in the given format. A single analysis is exported on its
+own, and several are exported side by side as a table.
+*/
+func writeAnalyses(w io.Writer, table *core.Table, format string, ascii bool) error {
+	single := len(table.Analyses) == 1
+	switch {
+	case format == "csv" && single:
+		return table.Analyses[0].WriteCSV(w)
+	case format == "csv":
+		return table.WriteCSV(w)
+	case format == "markdown" && single:
+		return table.Analyses[0].WriteMarkdown(w)
+	case format == "markdown":
+		return table.WriteMarkdown(w)
+	case format == "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if single {
+			return encoder.Encode(table.Analyses[0])
+		}
+		return encoder.Encode(table)
+	}
+
+	for i, A := range table.Analyses {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s\n\n", table.Labels[i])
+		printSummary(w, A)
+		fmt.Fprintln(w)
+		if err := render.Text(w, A, render.TextOptions{ASCII: ascii}); err != nil {
+			return err
+		}
+		if axes := A.Axes(); len(axes) > 0 {
+			fmt.Fprintln(w)
+			printSymbols(w, A, axes)
+		}
+	}
+	return nil
+}
+
+// Write an SVG chart of the analyses to a file
+func writeSVG(path string, series []render.Series, title string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := render.SVG(f, series, render.SVGOptions{Title: title}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Print the summary statistics of an analysis
+func printSummary(w io.Writer, A *core.Analysis) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	low, high := A.MeanInterval(core.PrecisionConfidence)
+	fmt.Fprintf(tw, "Rolls\t%d in %.2fs\n", A.N, A.Duration)
+	fmt.Fprintf(tw, "Mean\t%.3f (%.0f%% CI %.3f to %.3f)\n", A.Mean, core.PrecisionConfidence*100, low, high)
+	fmt.Fprintf(tw, "Deviation\t%.3f (up %.3f, down %.3f)\n", A.Deviation, A.DeviationUp, A.DeviationDown)
+	fmt.Fprintf(tw, "Range\t%d to %d\n", A.Min(), A.Max())
+	fmt.Fprintf(tw, "Median\t%d\n", A.Median())
+	fmt.Fprintf(tw, "Mode\t%d\n", A.Mode())
+	fmt.Fprintf(tw, "Percentiles\t5th %d, 25th %d, 75th %d, 95th %d\n",
+		A.Percentile(5), A.Percentile(25), A.Percentile(75), A.Percentile(95))
+	for _, tag := range sortedKeys(A.Tags) {
+		fmt.Fprintf(tw, "%s\t%.2f%%\n", strings.ToUpper(tag[:1])+tag[1:], A.TagRate(tag)*100)
+	}
+	tw.Flush()
+}
+
+// Print the chance of rolling each count of every symbol
+func printSymbols(w io.Writer, A *core.Analysis, axes []string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, axis := range axes {
+		marginal := A.Marginal(axis)
+		counts := make([]int, 0, len(marginal))
+		for count := range marginal {
+			counts = append(counts, count)
+		}
+		sort.Ints(counts)
+
+		chances := []string{}
+		for _, count := range counts {
+			chances = append(chances, fmt.Sprintf("%d: %.2f%%", count, float64(marginal[count])/float64(A.N)*100))
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", axis, strings.Join(chances, ", "))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/flywingedai/dice/core"
+)
+
+// dice compare <definition> <definition> [-n N] [--threads T] [--seed S]
+func compareCommand(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("compare", stderr)
+	sampling := addSamplingFlags(fs, defaultN)
+	sample := false
+	fs.BoolVar(&sample, "sample", false, "sample even when the exact answer can be calculated")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	definitions, err := loadDefinitions(positional, 2)
+	if err != nil {
+		return err
+	}
+
+	c, err := core.Compare(definitions[0], definitions[1], core.CompareOptions{
+		Sample:  sample,
+		N:       int(sampling.n),
+		Threads: int(sampling.threads),
+		Seed:    sampling.seed.seed,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "%s vs %s\n\n", positional[0], positional[1])
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	if c.Exact {
+		fmt.Fprintf(tw, "Method\texact\n")
+	} else {
+		fmt.Fprintf(tw, "Method\tsampled %d rolls\n", c.N)
+	}
+	fmt.Fprintf(tw, "Win\t%.2f%%\n", c.Win*100)
+	fmt.Fprintf(tw, "Tie\t%.2f%%\n", c.Tie*100)
+	fmt.Fprintf(tw, "Loss\t%.2f%%\n", c.Loss*100)
+	fmt.Fprintf(tw, "Mean difference\t%.3f\n", core.NewDistribution(c.Difference).Mean)
+	tw.Flush()
+	return nil
+}
@@ -0,0 +1,209 @@
+/*
+Command dice rolls and analyzes dice from the command line.
+
+	dice roll "3d6+2" --times 10 --seed 42
+	dice analyze "4d6kh3" -n 1e6 --threads 8
+	dice compare "2d6+3" "1d12+4"
+
+Definitions are written in dice notation, as accepted by dice.Parse(), or read
+from a JSON file saved from a *Definition by prefixing its path with "@", such
+as "dice roll @fireball.json". Flags can come before or after the definitions.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/flywingedai/dice"
+	"github.com/flywingedai/dice/core"
+)
+
+const usage = `Usage:
+  dice roll <definition> [--times N] [--seed S] [--json]
+  dice analyze <definition>... [-n N] [--threads T] [--seed S] [--format F] [--svg FILE]
+  dice compare <definition> <definition> [-n N] [--threads T] [--seed S]
+
+Analyzing several definitions shows them side by side, and --format csv,
+markdown or json exports the results instead of drawing a chart.
+
+A definition is dice notation, such as "4d6kh3" or "1d20+5", or "@file.json"
+for a definition saved as JSON. Run "dice <command> -h" for the flags of each
+command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Run the command line and return the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var command func([]string, io.Writer, io.Writer) error
+	switch args[0] {
+	case "roll":
+		command = rollCommand
+	case "analyze":
+		command = analyzeCommand
+	case "compare":
+		command = compareCommand
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "dice: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	err := command(args[1:], stdout, stderr)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "dice %s: %v\n", args[0], err)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "dice %s: %v\n", args[0], err)
+		return 1
+	}
+}
+
+// Error for command lines that can't be run, such as a missing definition
+var errUsage = errors.New("invalid usage")
+
+///////////
+// FLAGS //
+///////////
+
+/*
+Parse the flags of a command, allowing them to be mixed in with the positional
+arguments, which are returned in order.
+*/
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// Create the flag set for a command. Parse errors are returned rather than
+// printed, but help is still written to stderr.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("dice "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+/*
+A positive count which also accepts scientific notation, so "-n 1e6" is a
+million rolls.
+*/
+type countFlag int
+
+func (c *countFlag) String() string {
+	return strconv.Itoa(int(*c))
+}
+
+func (c *countFlag) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != float64(int(f)) || f < 1 {
+		return fmt.Errorf("%q is not a positive whole number", s)
+	}
+	*c = countFlag(f)
+	return nil
+}
+
+// An optional seed, which is nil until it is set.
+type seedFlag struct {
+	seed *int64
+}
+
+func (s *seedFlag) String() string {
+	if s.seed == nil {
+		return ""
+	}
+	return strconv.FormatInt(*s.seed, 10)
+}
+
+func (s *seedFlag) Set(value string) error {
+	seed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid seed", value)
+	}
+	s.seed = &seed
+	return nil
+}
+
+// The flags shared by analyze and compare
+type samplingFlags struct {
+	n       countFlag
+	threads countFlag
+	seed    seedFlag
+}
+
+func addSamplingFlags(fs *flag.FlagSet, n int) *samplingFlags {
+	f := &samplingFlags{n: countFlag(n), threads: countFlag(runtime.NumCPU())}
+	fs.Var(&f.n, "n", "number of rolls to sample, such as 1e6")
+	fs.Var(&f.threads, "threads", "number of goroutines to sample in")
+	fs.Var(&f.seed, "seed", "master seed for reproducible results")
+	return f
+}
+
+/////////////////
+// DEFINITIONS //
+/////////////////
+
+// Load a definition from dice notation, or from a JSON file prefixed by "@"
+func loadDefinition(arg string) (*core.Definition, error) {
+	if path, ok := strings.CutPrefix(arg, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		d, err := dice.LoadJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return d, nil
+	}
+	return dice.Parse(arg)
+}
+
+// Check the number of positional arguments and load each as a definition
+func loadDefinitions(args []string, count int) ([]*core.Definition, error) {
+	if len(args) != count {
+		return nil, fmt.Errorf("%w: expected %d definition(s), got %d", errUsage, count, len(args))
+	}
+	definitions := make([]*core.Definition, len(args))
+	for i, arg := range args {
+		d, err := loadDefinition(arg)
+		if err != nil {
+			return nil, err
+		}
+		definitions[i] = d
+	}
+	return definitions, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/flywingedai/dice/core"
+)
+
+// dice roll <definition> [--times N] [--seed S] [--json]
+func rollCommand(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("roll", stderr)
+	times := countFlag(1)
+	seed := seedFlag{}
+	asJSON := false
+	fs.Var(&times, "times", "number of times to roll")
+	fs.Var(&seed, "seed", "seed for reproducible rolls")
+	fs.BoolVar(&asJSON, "json", false, "print each result as a line of JSON")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	definitions, err := loadDefinitions(positional, 1)
+	if err != nil {
+		return err
+	}
+	d := definitions[0]
+	if seed.seed != nil {
+		d.SetSeed(*seed.seed)
+	}
+
+	encoder := json.NewEncoder(stdout)
+	for i := 0; i < int(times); i++ {
+		result, err := d.RollE()
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			if err := encoder.Encode(result); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if times > 1 {
+			fmt.Fprintf(stdout, "#%d ", i+1)
+		}
+		printResult(stdout, result, 0)
+	}
+	return nil
+}
+
+/*
+Print a result and its sub-results as an indented tree. Each line shows the
+total, the values that counted towards it when they differ from the
+sub-results, and any tags and symbols.
+*/
+func printResult(w io.Writer, result *core.Result, depth int) {
+	line := fmt.Sprintf("%s%d", strings.Repeat("  ", depth), result.Total)
+
+	if !result.Base && !sameValues(result) {
+		line += fmt.Sprintf(" %v", result.Values)
+	}
+	if len(result.Tags) > 0 {
+		line += " (" + strings.Join(sortedKeys(result.Tags), ", ") + ")"
+	}
+	if len(result.Symbols) > 0 {
+		symbols := []string{}
+		for _, symbol := range sortedKeys(result.Symbols) {
+			symbols = append(symbols, fmt.Sprintf("%s: %d", symbol, result.Symbols[symbol]))
+		}
+		line += " {" + strings.Join(symbols, ", ") + "}"
+	}
+	fmt.Fprintln(w, line)
+
+	for _, sub := range result.Results {
+		printResult(w, sub, depth+1)
+	}
+}
+
+// Whether the values of a result are just the totals of its sub-results
+func sameValues(result *core.Result) bool {
+	if len(result.Values) != len(result.Results) {
+		return false
+	}
+	for i, sub := range result.Results {
+		if result.Values[i] != sub.Total {
+			return false
+		}
+	}
+	return true
+}
+
+// The keys of a map in alphabetical order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
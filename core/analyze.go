@@ -1,7 +1,9 @@
 package core
 
 import (
-	"math"
+	"context"
+	"fmt"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,6 +29,16 @@ type Analysis struct {
 
 	// Timing information, in seconds
 	Duration float64
+
+	// The number of rolls that carried each tag, such as TAG_CRITICAL.
+	Tags map[string]int
+
+	/*
+		The number of rolls with each combination of symbols, keyed by
+		EncodeSymbols(). Rolls without any symbols are not counted here, so
+		Marginal() and Joint() should be used to read this.
+	*/
+	Symbols map[string]int
 }
 
 /*
@@ -51,23 +63,132 @@ func (a *Analysis) AtMost(N int) float64 {
 	return 1.0 - a.AtLeast(N+1)
 }
 
+/*
+Function for determine how often a roll carried a tag, such as the critical
+hit rate with TagRate(TAG_CRITICAL).
+*/
+func (a *Analysis) TagRate(tag string) float64 {
+	return float64(a.Tags[tag]) / float64(a.N)
+}
+
 // Analyze a roll for a given amount of time.
 func (d *Definition) AnalyzeTime(duration float64, threads int) *Analysis {
-	return d.analyze(0, duration, threads)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(duration*float64(time.Second)))
+	defer cancel()
+
+	A, err := d.AnalyzeContext(ctx, AnalyzeOptions{Threads: threads})
+	if err != nil {
+		panic(err)
+	}
+	return A
 }
 
-// Analyze a roll for a given number of rolls.
+// Analyze a roll for a given number of rolls. Panics if N is not positive, as
+// the analysis would never stop.
 func (d *Definition) AnalyzeN(N int, threads int) *Analysis {
-	return d.analyze(N, 0.0, threads)
+	checkN(N)
+	A, err := d.AnalyzeContext(context.Background(), AnalyzeOptions{N: N, Threads: threads})
+	if err != nil {
+		panic(err)
+	}
+	return A
+}
+
+// Analyze a roll for a given number of rolls, reproducibly from a master seed.
+// Panics if N is not positive, like AnalyzeN().
+func (d *Definition) AnalyzeNSeeded(N int, threads int, seed int64) *Analysis {
+	checkN(N)
+	A, err := d.AnalyzeContext(context.Background(), AnalyzeOptions{N: N, Threads: threads, Seed: &seed})
+	if err != nil {
+		panic(err)
+	}
+	return A
+}
+
+// AnalyzeContext rolls until the context is done when N <= 0, which never
+// happens for the background context used by AnalyzeN()
+func checkN(N int) {
+	if N <= 0 {
+		panic(fmt.Errorf("dice: analysis: %w: N must be positive, got %d", ErrInvalidParams, N))
+	}
+}
+
+//////////////
+// PROGRESS //
+//////////////
+
+// Options for AnalyzeContext.
+type AnalyzeOptions struct {
+
+	// The number of rolls to perform. If N is <= 0, rolls continue until the
+	// context is done.
+	N int
+
+	// The number of goroutines to roll in. Values below 1 are treated as 1.
+	Threads int
+
+	/*
+		Optional master seed. Each thread's source is derived from the master
+		seed and the thread's index, so analyzing the same definition with the
+		same N, Threads and Seed always produces the same rolls, regardless of
+		how the goroutines are scheduled. Analyses that stop on the context
+		rather than N are not reproducible, as the number of rolls depends on
+		timing. If nil, a random master seed is used.
+	*/
+	Seed *int64
+
+	/*
+		Optional function creating each thread's RNG from its derived seed,
+		such as NewPCG for faster rolling. Defaults to NewMathRand, which
+		matches SetSeed().
+	*/
+	NewRNG func(seed int64) RNG
+
+	// Optional callback for progress reports. It is called from the goroutine
+	// that called AnalyzeContext, every "ProgressInterval", and once more when
+	// the analysis finishes.
+	Progress func(Progress)
+
+	// How often to report progress. Defaults to DefaultProgressInterval.
+	ProgressInterval time.Duration
 }
 
+// A snapshot of a running analysis.
+type Progress struct {
+
+	// The number of rolls completed so far.
+	Rolls int
+
+	// The mean of the rolls completed so far.
+	Mean float64
+
+	// Time since the analysis started.
+	Elapsed time.Duration
+}
+
+// The default for "AnalyzeOptions.ProgressInterval".
+const DefaultProgressInterval = 250 * time.Millisecond
+
+////////////
+// ENGINE //
+////////////
+
 /*
-Basic analyze function. If N is positive, it will just calculate until all rolls
-are completed. If N is <= 0, then it will roll until "duration" time in seconds
-have passed. (And then process the results of the roll. So in practice, it will
-take a little longer than the duration requested.)
+Analyze a roll until either "opts.N" rolls are completed, or the context is
+done. Stopping because the context is done is the normal way to finish when N
+is <= 0, and the completed analysis is returned without an error. When N is
+positive and the context is done first, the partial analysis is returned along
+with the context's error.
+
+An error is also returned, without an analysis, if the definition is invalid
+or a roll fails part way through, see RollE().
 */
-func (d *Definition) analyze(N int, duration float64, threads int) *Analysis {
+func (d *Definition) AnalyzeContext(ctx context.Context, opts AnalyzeOptions) (*Analysis, error) {
+
+	// Make sure the definition is valid before handing it to the goroutines
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Constant for number of rolls to complete in each batch
 	batchSize := 1024
@@ -75,172 +196,189 @@ func (d *Definition) analyze(N int, duration float64, threads int) *Analysis {
 	// Keep track of timing
 	startTime := time.Now()
 
-	// Determine the number of tests to perform in each thread.
-	threadN := N / threads
-	N = threadN * threads
+	threads := max(opts.Threads, 1)
 
-	// This is where all the subresults get sent.
-	A := &Analysis{
-		Rolls: map[int]int{},
-		N:     N,
+	master := newSeed()
+	if opts.Seed != nil {
+		master = *opts.Seed
 	}
 
-	// We need a couple control channels for handling the different process steps
-	totalChannel := make(chan int)
-	meanChannel := make(chan float64)
-	varianceChannel := make(chan [5]float64)
-	mapChannel := make(chan map[int]int)
-	stopChannel := make(chan bool)
+	// Running totals shared by every thread for progress reports
+	var rolls, total atomic.Int64
+
+	// Each thread sends back its rolls exactly once, so the channel never blocks
+	results := make(chan threadResult, threads)
+
+	// Stop every thread as soon as one of them fails
+	rollCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	/*
 		In order to run many of these in parallel, we need to create "thread"
 		copies of the original definition, each with it's own random number
-		generator. We will dispatch each of these into a goroutine which will
-		respond with an analysis object that will be combined later.
+		generator derived from the master seed. We will dispatch each of these
+		into a goroutine which will respond with the rolls it made when it is
+		done.
 	*/
 	for i := 0; i < threads; i++ {
 
-		/*
-			Each thread's logic is very simple. Just run a crapton of trials and
-			record all the results in the analysis object. We will not keep
-			track of the standard deviation here as we need to run a post
-			analysis afterward.
-		*/
-		go func() {
-
-			// Create the new Definition object and set a new seed value.
-			definition := d.Copy()
-			definition = definition.SetRandomSource()
+		// Split N as evenly as possible between the threads
+		quota := opts.N / threads
+		if i < opts.N%threads {
+			quota++
+		}
 
-			// Setup a separate channel for continuing processing
-			processingChannel := make(chan bool, 1)
-			processingChannel <- true
+		go func(quota int, seed int64) {
 
-			// Now we just do a shitton of rolls and keep track of the total
-			total := 0
-			rollMap := map[int]int{}
+			R := newAnalysis()
+			var err error
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("dice: analysis: %w: %v", ErrRollFailed, r)
+				}
+				if err != nil {
+					cancel()
+				}
+				results <- threadResult{analysis: R, err: err}
+			}()
 
-			/*
-				The control loop is the main processing loop. We'll process in
-				larger groups to reduce overhead. Plus, processing rolls should
-				not take too long in the first place.
-			*/
-		controlLoop:
-			for j := 0; j < threadN/batchSize || N <= 0; j++ {
+			// Create the new Definition object and set its seed value.
+			definition := d.Copy()
+			if opts.NewRNG != nil {
+				definition = definition.SetSource(opts.NewRNG(seed))
+			} else {
+				definition = definition.SetSeed(seed)
+			}
 
-				// Use the stop channel to determine if time has elapsed
+			// Process in batches to reduce the overhead of checking the context
+			for done := 0; opts.N <= 0 || done < quota; {
 				select {
-				case <-stopChannel:
-					break controlLoop
+				case <-rollCtx.Done():
+					return
+				default:
+				}
 
-				// If the stop channel hasn't been triggered, or we are just processing by
-				// 	roll count, we will just continue processing rolls.
-				case <-processingChannel:
+				batch := batchSize
+				if opts.N > 0 {
+					batch = min(batch, quota-done)
+				}
 
-					for k := 0; k < batchSize; k++ {
-						result := definition.Roll()
-						total += result.Total
-						rollMap[result.Total] += 1
+				batchTotal := 0
+				for k := 0; k < batch; k++ {
+					var result *Result
+					if result, err = definition.RollE(); err != nil {
+						return
 					}
-
-					// We need to trigger the next process loop by sending another bump
-					// 	to the processing channel. The outer "j" loop will stop us once
-					// 	we've processed enough rolls.
-					processingChannel <- true
-
+					batchTotal += result.Total
+					R.add(result)
 				}
 
+				done += batch
+				rolls.Add(int64(batch))
+				total.Add(int64(batchTotal))
 			}
 
-			// Send the all the rolls, the calculated total, and the total channel and then await the
-			// 	calculated mean response.
-			mapChannel <- rollMap
-			totalChannel <- total
-			mean := <-meanChannel
-
-			// Now we calculate the variance for this routine
-			variance := 0.0
-			varianceUp := 0.0
-			upCount := 0.0
-			varianceDown := 0.0
-			downCount := 0.0
-			for k, v := range rollMap {
-				deviation := float64(v) * math.Pow(mean-float64(k), 2)
-				variance += deviation
-
-				percent := (math.Tanh(float64(k)-mean) + 1.0) / 2.0
-
-				upCount += float64(v) * percent
-				downCount += float64(v) * (1.0 - percent)
-				varianceUp += deviation * percent
-				varianceDown += deviation * (1.0 - percent)
-			}
-
-			/*
-				Once the variance is calculated, we will send it back through
-				the varianceChannel along with the calculated roll map.
-			*/
-			varianceChannel <- [5]float64{variance, varianceUp, upCount, varianceDown, downCount}
-
-			// Now we safely end as all the processing we need to do has been done.
-
-		}()
+		}(quota, deriveSeed(master, i))
 
 	}
 
-	// Send a stop command if a duration is specified
-	if N <= 0 {
-		<-time.NewTicker(time.Duration(duration * float64(time.Second))).C
-		for i := 0; i < threads; i++ {
-			stopChannel <- true
+	// Only tick if there is someone to report progress to
+	var tick <-chan time.Time
+	if opts.Progress != nil {
+		interval := opts.ProgressInterval
+		if interval <= 0 {
+			interval = DefaultProgressInterval
 		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
 	}
-
-	// Now we need to construct the total heatmap of results
-	totalRolls := 0
-	for j := 0; j < threads; j++ {
-		M := <-mapChannel
-		for k, v := range M {
-			A.Rolls[k] += v
-			totalRolls += v
+	progress := func() Progress {
+		n := rolls.Load()
+		p := Progress{Rolls: int(n), Elapsed: time.Since(startTime)}
+		if n > 0 {
+			p.Mean = float64(total.Load()) / float64(n)
 		}
+		return p
 	}
 
-	// As the completed results come in, we want to create a meta-analysis object which
-	// 	combines all the information into one place. First, we will analyze and send back the mean
-	total := float64(0)
-	for j := 0; j < threads; j++ {
-		total += float64(<-totalChannel)
-	}
-	A.Mean = total / float64(totalRolls)
+	// Combine the rolls from each thread as they finish
+	A := newAnalysis()
+	var failed error
+	for remaining := threads; remaining > 0; {
+		select {
+		case R := <-results:
+			A.merge(R.analysis)
+			if failed == nil {
+				failed = R.err
+			}
+			remaining--
 
-	// We now send the means back to all the individual channels
-	for j := 0; j < threads; j++ {
-		meanChannel <- A.Mean
+		case <-tick:
+			opts.Progress(progress())
+		}
 	}
-
-	// Now we need to extract the total variance
-	variance := 0.0
-	varianceUp := 0.0
-	upCount := 0.0
-	varianceDown := 0.0
-	downCount := 0.0
-	for j := 0; j < threads; j++ {
-		variances := <-varianceChannel
-		variance += variances[0]
-		varianceUp += variances[1]
-		upCount += variances[2]
-		varianceDown += variances[3]
-		downCount += variances[4]
+	if opts.Progress != nil {
+		opts.Progress(progress())
+	}
+	if failed != nil {
+		return nil, failed
 	}
-	A.Deviation = math.Pow(variance/float64(totalRolls), 0.5)
-	A.DeviationUp = math.Pow(varianceUp/upCount, 0.5)
-	A.DeviationDown = math.Pow(varianceDown/downCount, 0.5)
 
-	A.N = totalRolls
+	A.summarize()
 	A.Duration = float64(time.Since(startTime)) / float64(time.Second)
 
-	// Once everything is aggregated, return the completed analysis object.
-	return A
+	if opts.N > 0 && A.N < opts.N {
+		return A, ctx.Err()
+	}
+	return A, nil
+
+}
+
+// The rolls made by one thread of AnalyzeContext, and why it stopped early
+type threadResult struct {
+	analysis *Analysis
+	err      error
+}
+
+// Create an empty analysis, ready for rolls to be added
+func newAnalysis() *Analysis {
+	return &Analysis{Rolls: map[int]int{}, Tags: map[string]int{}, Symbols: map[string]int{}}
+}
 
+// Count a single roll. The summary statistics need to be recalculated
+// afterward.
+func (a *Analysis) add(result *Result) {
+	a.Rolls[result.Total] += 1
+	for tag := range result.Tags {
+		a.Tags[tag] += 1
+	}
+	if len(result.Symbols) > 0 {
+		if key := EncodeSymbols(result.Symbols); key != "" {
+			a.Symbols[key] += 1
+		}
+	}
+}
+
+// Add the rolls and tags of another analysis to this one. The summary
+// statistics need to be recalculated afterward.
+func (a *Analysis) merge(b *Analysis) {
+	for k, v := range b.Rolls {
+		a.Rolls[k] += v
+	}
+	for k, v := range b.Tags {
+		a.Tags[k] += v
+	}
+	for k, v := range b.Symbols {
+		a.Symbols[k] += v
+	}
+}
+
+// Fill out the count and summary statistics from the "Rolls" map.
+func (a *Analysis) summarize() {
+	a.N = 0
+	for _, count := range a.Rolls {
+		a.N += count
+	}
+	a.Mean, a.Deviation, a.DeviationUp, a.DeviationDown = moments(a.Rolls)
 }
@@ -1,5 +1,7 @@
 package core
 
+import "fmt"
+
 /*
 TODO
 */
@@ -29,6 +31,347 @@ func (d *Definition) Disadvantage() *Definition {
 	return newDefinition
 }
 
+/*
+Exploding roll. Whenever the definition rolls at least "threshold", it is
+rolled again and added to the total, up to "maxDepth" extra rolls. Every roll
+is kept in the Result's "Results".
+
+Inside a pool like New(6).Explode(6, 10).Multiple(4), each die keeps its own extra
+rolls, so the explosions compound onto the die that exploded. Use ExplodePool()
+for extra rolls that join the pool as dice of their own.
+*/
+func (d *Definition) Explode(threshold, maxDepth int) *Definition {
+	newDefinition := new(d, ROLL_EXPLODE, AGGREGATE_SUM)
+	newDefinition.RollParams["threshold"] = threshold
+	newDefinition.RollParams["maxDepth"] = maxDepth
+	return newDefinition
+}
+
+/*
+Penetrating version of Explode(). Every extra roll is worth one less than was
+rolled, but the threshold is still checked against the raw roll.
+*/
+func (d *Definition) ExplodePenetrating(threshold, maxDepth int) *Definition {
+	newDefinition := d.Explode(threshold, maxDepth)
+	newDefinition.RollParams["penetrate"] = true
+	return newDefinition
+}
+
+/*
+Pool of "n" exploding dice. Whenever one of the rolls is at least "threshold",
+another die is rolled and added to the pool, up to "maxDepth" extra rolls for
+each of the "n" dice. The extra dice are separate rolls in the Result's
+"Results", so they can be kept, dropped and counted like the original dice,
+such as the 10-again of dice.New(10).ExplodePool(5, 10, 100).CountAtLeast(8).
+*/
+func (d *Definition) ExplodePool(n, threshold, maxDepth int) *Definition {
+	newDefinition := new(d, ROLL_EXPLODE_POOL, AGGREGATE_SUM)
+	newDefinition.RollParams["count"] = n
+	newDefinition.RollParams["threshold"] = threshold
+	newDefinition.RollParams["maxDepth"] = maxDepth
+	return newDefinition
+}
+
+/*
+Penetrating version of ExplodePool(). Every extra die is worth one less than
+was rolled, but the threshold is still checked against the raw roll.
+*/
+func (d *Definition) ExplodePoolPenetrating(n, threshold, maxDepth int) *Definition {
+	newDefinition := d.ExplodePool(n, threshold, maxDepth)
+	newDefinition.RollParams["penetrate"] = true
+	return newDefinition
+}
+
+/*
+Reroll the definition whenever its total matches the condition. With "once"
+set, only a single reroll is made and the new roll is kept even if it is
+worse, like rerolling 1s and 2s with Great Weapon Fighting:
+
+	dice.New(6).Reroll(core.AtMost(2), true)
+
+Otherwise the definition is rerolled until the condition no longer matches, up
+to a limit of 100 rerolls. The discarded rolls are kept in the Result's
+"Results", with the kept roll last.
+*/
+func (d *Definition) Reroll(condition Condition, once bool) *Definition {
+	newDefinition := new(d, ROLL_REROLL, AGGREGATE_LAST)
+	newDefinition.RollParams["condition"] = condition
+	newDefinition.RollParams["once"] = once
+	return newDefinition
+}
+
+///////////////////
+// KEEP AND DROP //
+///////////////////
+
+/*
+Only count the highest n rolls of a pool towards the total, such as rolling
+stats with dice.New(6).Multiple(4).KeepHighest(3). The rolls are sorted in
+ascending order in the Result's "Results".
+*/
+func (d *Definition) KeepHighest(n int) *Definition {
+	newDefinition := reaggregate(d, AGGREGATE_KEEP_HIGHEST)
+	newDefinition.AggregationParams["count"] = n
+	return newDefinition
+}
+
+/*
+Only count the lowest n rolls of a pool towards the total. The rolls are
+sorted in ascending order in the Result's "Results".
+*/
+func (d *Definition) KeepLowest(n int) *Definition {
+	newDefinition := reaggregate(d, AGGREGATE_KEEP_LOWEST)
+	newDefinition.AggregationParams["count"] = n
+	return newDefinition
+}
+
+/*
+Count every roll of a pool towards the total except the highest n. The rolls
+are sorted in ascending order in the Result's "Results".
+*/
+func (d *Definition) DropHighest(n int) *Definition {
+	newDefinition := reaggregate(d, AGGREGATE_DROP_HIGHEST)
+	newDefinition.AggregationParams["count"] = n
+	return newDefinition
+}
+
+/*
+Count every roll of a pool towards the total except the lowest n. The rolls
+are sorted in ascending order in the Result's "Results".
+*/
+func (d *Definition) DropLowest(n int) *Definition {
+	newDefinition := reaggregate(d, AGGREGATE_DROP_LOWEST)
+	newDefinition.AggregationParams["count"] = n
+	return newDefinition
+}
+
+////////////////
+// ARITHMETIC //
+////////////////
+
+/*
+Roll both definitions and add their totals, such as
+dice.New(8).Add(dice.New(6)) for "1d8 + 1d6".
+*/
+func (d *Definition) Add(other *Definition) *Definition {
+	newDefinition := new(d, ROLL_SKIP, AGGREGATE_SUM)
+	newDefinition.Children = append(newDefinition.Children, other)
+	return newDefinition
+}
+
+/*
+Roll both definitions and subtract the other's total from this one.
+*/
+func (d *Definition) Sub(other *Definition) *Definition {
+	newDefinition := new(d, ROLL_SKIP, AGGREGATE_SUBTRACT)
+	newDefinition.Children = append(newDefinition.Children, other)
+	return newDefinition
+}
+
+/*
+Multiply the total by a constant factor, such as dice.New(10).Mul(2) for
+"2 × 1d10".
+*/
+func (d *Definition) Mul(k int) *Definition {
+	newDefinition := new(d, ROLL_SKIP, AGGREGATE_SCALE)
+	newDefinition.AggregationParams["factor"] = k
+	return newDefinition
+}
+
+/*
+Divide the total by a constant divisor, rounding with one of ROUND_DOWN,
+ROUND_UP or ROUND_NEAREST.
+*/
+func (d *Definition) Div(k int, rounding string) *Definition {
+	newDefinition := new(d, ROLL_SKIP, AGGREGATE_DIVIDE)
+	newDefinition.AggregationParams["divisor"] = k
+	newDefinition.AggregationParams["rounding"] = rounding
+	return newDefinition
+}
+
+/*
+Negate the total.
+*/
+func (d *Definition) Neg() *Definition {
+	return d.Mul(-1)
+}
+
+/*
+Add a constant modifier to the total, such as dice.New(8).Plus(3) for
+"1d8 + 3".
+*/
+func (d *Definition) Plus(n int) *Definition {
+	return d.Add(&Definition{
+		Children:   []*Definition{},
+		RollType:   ROLL_CONSTANT,
+		RollParams: map[string]interface{}{"value": n},
+	})
+}
+
+/*
+Multiply the rolls together instead of adding them. This works on pools, like
+dice.New(6).Multiple(2).Product(), and on combined definitions, like
+dice.New(6).Add(dice.New(4)).Product().
+*/
+func (d *Definition) Product() *Definition {
+	return reaggregate(d, AGGREGATE_PRODUCT)
+}
+
+////////////////
+// SELECTIONS //
+////////////////
+
+/*
+Only count the highest roll, such as dice.New(6).Multiple(3).Max().
+*/
+func (d *Definition) Max() *Definition {
+	return reaggregate(d, AGGREGATE_MAX)
+}
+
+/*
+Only count the lowest roll, such as dice.New(6).Multiple(3).Min().
+*/
+func (d *Definition) Min() *Definition {
+	return reaggregate(d, AGGREGATE_MIN)
+}
+
+/*
+Only count the median roll, such as dice.New(20).Multiple(3).Median(). With an
+even number of rolls, the middle two are averaged and rounded down.
+*/
+func (d *Definition) Median() *Definition {
+	return reaggregate(d, AGGREGATE_MEDIAN)
+}
+
+////////////////
+// DICE POOLS //
+////////////////
+
+/*
+Count how many rolls of a pool are at least the target rather than summing
+them, so the Result's "Total" is the number of successes. For example, a
+World of Darkness pool of 5 dice would be dice.New(10).Multiple(5).CountAtLeast(8).
+*/
+func (d *Definition) CountAtLeast(target int) *Definition {
+	return d.CountSuccesses(AtLeast(target), nil, nil)
+}
+
+/*
+Count how many rolls of a pool match the success condition. Rolls equal to a
+"botch" value subtract a success, and rolls equal to a "doubles" value count as
+two successes. Either can be nil.
+*/
+func (d *Definition) CountSuccesses(success Condition, botch, doubles []int) *Definition {
+	if botch == nil {
+		botch = []int{}
+	}
+	if doubles == nil {
+		doubles = []int{}
+	}
+
+	newDefinition := reaggregate(d, AGGREGATE_COUNT)
+	newDefinition.AggregationParams["success"] = success
+	newDefinition.AggregationParams["botch"] = botch
+	newDefinition.AggregationParams["doubles"] = doubles
+	return newDefinition
+}
+
+///////////////////
+// OPPOSED ROLLS //
+///////////////////
+
+/*
+Roll the definition against a defender, with the total being the margin of
+success. The margin is negative when the defender rolls higher. The attacker
+and defender are kept, in that order, in the Result's "Results".
+*/
+func (d *Definition) Opposed(defender *Definition) *Definition {
+	newDefinition := new(d, ROLL_OPPOSED, AGGREGATE_OPPOSED)
+	newDefinition.Children = append(newDefinition.Children, defender)
+	newDefinition.AggregationParams["mode"] = OPPOSED_MARGIN
+	newDefinition.AggregationParams["ties"] = TIES_TIE
+	return newDefinition
+}
+
+/*
+Roll the definition against a defender, with the total being 1 if the
+attacker wins, -1 if the defender wins and 0 for a tie. "ties" is one of
+TIES_TIE, TIES_ATTACKER or TIES_DEFENDER, and decides who wins a tie.
+*/
+func (d *Definition) Contest(defender *Definition, ties string) *Definition {
+	newDefinition := d.Opposed(defender)
+	newDefinition.AggregationParams["mode"] = OPPOSED_OUTCOME
+	newDefinition.AggregationParams["ties"] = ties
+	return newDefinition
+}
+
+///////////////
+// BRANCHING //
+///////////////
+
+/*
+Use the definition as a test, and roll the outcome for the first branch its
+total matches. There must be one outcome for each branch, plus optionally one
+more that is rolled when no branch matches. Without it, the total is 0 when no
+branch matches.
+
+For example, an attack with a +5 bonus against an AC of 15, doing double
+damage dice on a natural 20, tests the raw d20 and moves the bonus into the
+branches:
+
+	attack := dice.New(20).CriticalOn(20).Conditional(
+		[]core.Branch{core.BranchValues(20), core.BranchAtLeast(15 - 5)},
+		dice.New(8).Multiple(2).Plus(3),
+		dice.New(8).Plus(3),
+	)
+
+The Result's "Results" holds the test and then the rolled outcome, and the
+total is the outcome's total. Tags from both are kept, so only the test is
+tagged here and TagRate(TAG_CRITICAL) is the chance of a natural 20.
+*/
+func (d *Definition) Conditional(branches []Branch, outcomes ...*Definition) *Definition {
+	newDefinition := new(d, ROLL_CONDITIONAL, AGGREGATE_LAST)
+	newDefinition.Children = append(newDefinition.Children, outcomes...)
+	newDefinition.RollParams["branches"] = branches
+	return newDefinition
+}
+
+//////////
+// TAGS //
+//////////
+
+/*
+Tag rolls of at least "threshold" with TAG_CRITICAL, such as
+dice.New(20).CriticalOn(19) for a weapon that crits on a 19 or 20.
+
+Tags are opt-in: a base die created with dice.New() or dice.NewWeighted() only
+tags its rolls once CriticalOn() or FumbleOn() has been called on it, so
+plain damage dice never count towards Analysis.TagRate(). Other definitions
+can't be tagged, so this panics with an error wrapping ErrInvalidParams if
+called on anything else, such as dice.New(20).Multiple(2). Tag the die before
+building on it instead.
+
+Aggregations pass up the tags of the rolls that count towards the total. A
+pool such as dice.New(20).CriticalOn(20).Multiple(2).KeepHighest(1) counts
+its critical from the kept die only, while a pool that keeps every die is
+tagged if any of its dice is, and its tag count is the number of tagged dice.
+Analyses count a roll as tagged once, however many of its dice were tagged.
+Conditional() keeps the test's tags as well as the rolled branch's, so tag
+the test rather than the branches to measure how often a branch is hit.
+*/
+func (d *Definition) CriticalOn(threshold int) *Definition {
+	return tag(d, "CriticalOn", "critical", threshold)
+}
+
+/*
+Tag rolls of at most "threshold" with TAG_FUMBLE, such as
+dice.New(20).FumbleOn(1). Like CriticalOn(), this only applies to base dice
+created with dice.New() or dice.NewWeighted(), and panics otherwise.
+*/
+func (d *Definition) FumbleOn(threshold int) *Definition {
+	return tag(d, "FumbleOn", "fumble", threshold)
+}
+
 ///////////////////
 // CHAIN HELPERS //
 ///////////////////
@@ -47,3 +390,71 @@ func new(d *Definition, rollType, aggregationType string) *Definition {
 		Children: []*Definition{d},
 	}
 }
+
+/*
+Creates a copy of the definition with a different aggregation. The children
+are shared with the original definition, which is left unchanged.
+*/
+func reaggregate(d *Definition, aggregationType string) *Definition {
+	newDefinition := clone(d)
+	newDefinition.AggregationType = aggregationType
+	newDefinition.AggregationParams = map[string]interface{}{}
+	return newDefinition
+}
+
+// Creates a copy of a base die that tags its rolls, see CriticalOn()
+func tag(d *Definition, method, param string, threshold int) *Definition {
+	if d.RollType != ROLL_SIDES && d.RollType != ROLL_WEIGHTED {
+		panic(fmt.Errorf("dice: %s: %w: only dice from New() or NewWeighted() can be tagged, not a %q roll", method, ErrInvalidParams, d.RollType))
+	}
+	newDefinition := clone(d)
+	newDefinition.RollParams[param] = threshold
+	return newDefinition
+}
+
+/*
+Creates a copy of the definition node with its own params, so they can be
+changed without affecting the original. The children are shared.
+*/
+func clone(d *Definition) *Definition {
+	rollParams := map[string]interface{}{}
+	for key, value := range d.RollParams {
+		rollParams[key] = value
+	}
+
+	aggregationParams := map[string]interface{}{}
+	for key, value := range d.AggregationParams {
+		aggregationParams[key] = value
+	}
+
+	return &Definition{
+		source: d.source,
+
+		RollType:        d.RollType,
+		AggregationType: d.AggregationType,
+
+		RollParams:        rollParams,
+		AggregationParams: aggregationParams,
+
+		Children: append([]*Definition{}, d.Children...),
+	}
+}
+
+/////////////
+// SYMBOLS //
+/////////////
+
+/*
+Cancel opposing symbols against each other, such as
+pool.Cancel(map[string]string{"success": "failure", "advantage": "threat"}, "success")
+for narrative dice. Only the larger of each pair is left on the Result's
+"Symbols", reduced by the smaller. If "total" names a symbol, the total
+becomes its net count, so AtLeast(1) is the chance of at least one net
+success. Pass "" to keep the total of the definition.
+*/
+func (d *Definition) Cancel(pairs map[string]string, total string) *Definition {
+	newDefinition := new(d, ROLL_SKIP, AGGREGATE_CANCEL)
+	newDefinition.AggregationParams["pairs"] = pairs
+	newDefinition.AggregationParams["total"] = total
+	return newDefinition
+}
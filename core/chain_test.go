@@ -0,0 +1,29 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTagNotBase(t *testing.T) {
+	d20 := &Definition{RollType: ROLL_SIDES, RollParams: map[string]interface{}{"sides": 20}}
+	if critical := d20.CriticalOn(19); critical.RollParams["critical"] != 19 || d20.RollParams["critical"] != nil {
+		t.Errorf("CriticalOn(19) params = %v, original %v", critical.RollParams, d20.RollParams)
+	}
+
+	tags := map[string]func(){
+		"CriticalOn": func() { d20.Multiple(2).CriticalOn(20) },
+		"FumbleOn":   func() { d20.Plus(1).FumbleOn(1) },
+	}
+	for name, tag := range tags {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrInvalidParams) {
+					t.Errorf("%s: recovered %v, want ErrInvalidParams", name, err)
+				}
+			}()
+			tag()
+		}()
+	}
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"runtime"
+)
+
+// The number of rolls Compare samples when "CompareOptions.N" isn't set.
+const DefaultCompareN = 1 << 20
+
+// Options for Compare.
+type CompareOptions struct {
+
+	// Always sample, even when the exact distribution could be calculated.
+	Sample bool
+
+	// The number of rolls to sample. Defaults to DefaultCompareN.
+	N int
+
+	// The number of goroutines to sample in. Defaults to runtime.NumCPU().
+	Threads int
+
+	// Optional master seed for reproducible sampling. See AnalyzeOptions.
+	Seed *int64
+
+	// Optional function creating each thread's RNG. See AnalyzeOptions.
+	NewRNG func(seed int64) RNG
+}
+
+// The Comparison object describes how two definitions compare to each other.
+type Comparison struct {
+
+	// The probability of the first definition rolling higher, the same, or
+	// lower than the second.
+	Win  float64
+	Tie  float64
+	Loss float64
+
+	// The probability of each difference between the first total and the
+	// second total.
+	Difference map[int]float64
+
+	// Whether the comparison was calculated exactly, or sampled.
+	Exact bool
+
+	// The number of rolls sampled, or 0 if the comparison is exact.
+	N int
+}
+
+/*
+Compare two definitions, such as how often "2d6+3" beats "1d12+4". The exact
+distribution of the difference is used when every node of both definitions
+supports it, otherwise the difference is sampled with AnalyzeContext. An error
+is only returned if one of the definitions is invalid.
+*/
+func Compare(a, b *Definition, opts CompareOptions) (*Comparison, error) {
+	difference := a.Sub(b)
+	if err := difference.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !opts.Sample {
+		if distribution, err := difference.Distribution(); err == nil {
+			return newComparison(distribution.Probabilities, true, 0), nil
+		}
+	}
+
+	if opts.N <= 0 {
+		opts.N = DefaultCompareN
+	}
+	if opts.Threads <= 0 {
+		opts.Threads = runtime.NumCPU()
+	}
+
+	A, err := difference.AnalyzeContext(context.Background(), AnalyzeOptions{
+		N:       opts.N,
+		Threads: opts.Threads,
+		Seed:    opts.Seed,
+		NewRNG:  opts.NewRNG,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	probabilities := map[int]float64{}
+	for value, count := range A.Rolls {
+		probabilities[value] = float64(count) / float64(A.N)
+	}
+	return newComparison(probabilities, false, A.N), nil
+}
+
+// Create a new *Comparison from the distribution of differences
+func newComparison(difference map[int]float64, exact bool, N int) *Comparison {
+	c := &Comparison{
+		Difference: difference,
+		Exact:      exact,
+		N:          N,
+	}
+	for value, probability := range difference {
+		switch {
+		case value > 0:
+			c.Win += probability
+		case value < 0:
+			c.Loss += probability
+		default:
+			c.Tie += probability
+		}
+	}
+	return c
+}
@@ -0,0 +1,130 @@
+package core
+
+import "fmt"
+
+/*
+A Condition compares a roll's total against a value, such as "less than 3" for
+rerolls. Conditions are plain data so they can be stored in the params of a
+Definition and saved as JSON.
+*/
+type Condition struct {
+	Operator string `json:"operator"`
+	Value    int    `json:"value"`
+}
+
+// Create a Condition that matches totals equal to the value
+func Equal(value int) Condition {
+	return Condition{Operator: COMPARE_EQUAL, Value: value}
+}
+
+// Create a Condition that matches totals not equal to the value
+func NotEqual(value int) Condition {
+	return Condition{Operator: COMPARE_NOT_EQUAL, Value: value}
+}
+
+// Create a Condition that matches totals below the value
+func LessThan(value int) Condition {
+	return Condition{Operator: COMPARE_LESS, Value: value}
+}
+
+// Create a Condition that matches totals at or below the value
+func AtMost(value int) Condition {
+	return Condition{Operator: COMPARE_LESS_EQUAL, Value: value}
+}
+
+// Create a Condition that matches totals above the value
+func GreaterThan(value int) Condition {
+	return Condition{Operator: COMPARE_GREATER, Value: value}
+}
+
+// Create a Condition that matches totals at or above the value
+func AtLeast(value int) Condition {
+	return Condition{Operator: COMPARE_GREATER_EQUAL, Value: value}
+}
+
+// Check whether a total satisfies the condition
+func (c Condition) Matches(total int) bool {
+	switch c.Operator {
+	case COMPARE_EQUAL:
+		return total == c.Value
+	case COMPARE_NOT_EQUAL:
+		return total != c.Value
+	case COMPARE_LESS:
+		return total < c.Value
+	case COMPARE_LESS_EQUAL:
+		return total <= c.Value
+	case COMPARE_GREATER:
+		return total > c.Value
+	case COMPARE_GREATER_EQUAL:
+		return total >= c.Value
+	}
+	return false
+}
+
+// Check that the operator is one of the known COMPARE constants
+func (c Condition) Validate() error {
+	switch c.Operator {
+	case COMPARE_EQUAL, COMPARE_NOT_EQUAL, COMPARE_LESS, COMPARE_LESS_EQUAL, COMPARE_GREATER, COMPARE_GREATER_EQUAL:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown condition operator %q", ErrInvalidParams, c.Operator)
+}
+
+func (c Condition) String() string {
+	return fmt.Sprintf("%s%d", c.Operator, c.Value)
+}
+
+//////////////
+// BRANCHES //
+//////////////
+
+/*
+A Branch selects a branch of a conditional Definition based on the total of
+its test roll. A total matches if it is within the inclusive "Min" and "Max"
+bounds, where nil bounds are open, and, if "Values" is not empty, is one of the
+listed values.
+*/
+type Branch struct {
+	Min    *int  `json:"min,omitempty"`
+	Max    *int  `json:"max,omitempty"`
+	Values []int `json:"values,omitempty"`
+}
+
+// Create a Branch matching totals from min to max inclusive
+func BranchRange(min, max int) Branch {
+	return Branch{Min: &min, Max: &max}
+}
+
+// Create a Branch matching totals of at least min
+func BranchAtLeast(min int) Branch {
+	return Branch{Min: &min}
+}
+
+// Create a Branch matching totals of at most max
+func BranchAtMost(max int) Branch {
+	return Branch{Max: &max}
+}
+
+// Create a Branch matching only the listed totals
+func BranchValues(values ...int) Branch {
+	return Branch{Values: values}
+}
+
+// Check whether a total selects the branch
+func (b Branch) Matches(total int) bool {
+	if b.Min != nil && total < *b.Min {
+		return false
+	}
+	if b.Max != nil && total > *b.Max {
+		return false
+	}
+	if len(b.Values) > 0 {
+		for _, value := range b.Values {
+			if total == value {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
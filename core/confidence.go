@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+/*
+The confidence level used by AnalyzeUntilPrecision when deciding whether the
+mean is precise enough.
+*/
+const PrecisionConfidence = 0.95
+
+/////////////////////
+// STANDARD ERRORS //
+/////////////////////
+
+// The standard error of "Mean", as an estimate of the true mean.
+func (a *Analysis) StandardError() float64 {
+	return a.Deviation / math.Sqrt(float64(a.N))
+}
+
+// The standard error of "AtLeast(N)", as an estimate of the true probability.
+func (a *Analysis) AtLeastStandardError(N int) float64 {
+	p := a.AtLeast(N)
+	return math.Sqrt(p * (1 - p) / float64(a.N))
+}
+
+// The standard error of "AtMost(N)", as an estimate of the true probability.
+func (a *Analysis) AtMostStandardError(N int) float64 {
+	return a.AtLeastStandardError(N + 1)
+}
+
+//////////////////////////
+// CONFIDENCE INTERVALS //
+//////////////////////////
+
+/*
+A confidence interval for the true mean using the normal approximation. A
+confidence of 0.95 gives the 95% confidence interval.
+*/
+func (a *Analysis) MeanInterval(confidence float64) (low, high float64) {
+	margin := zScore(confidence) * a.StandardError()
+	return a.Mean - margin, a.Mean + margin
+}
+
+/*
+A confidence interval for the true probability of a roll being at least N,
+using the Wilson score interval. Unlike the normal approximation, this stays
+between 0 and 1 and behaves well for rare events, such as AtLeast(20) on a
+d20.
+*/
+func (a *Analysis) AtLeastInterval(N int, confidence float64) (low, high float64) {
+	return wilson(a.AtLeast(N), float64(a.N), zScore(confidence))
+}
+
+/*
+A confidence interval for the true probability of a roll being at most N,
+using the Wilson score interval.
+*/
+func (a *Analysis) AtMostInterval(N int, confidence float64) (low, high float64) {
+	return wilson(a.AtMost(N), float64(a.N), zScore(confidence))
+}
+
+/////////////////////
+// PRECISION LOOPS //
+/////////////////////
+
+/*
+Analyze a roll until the 95% confidence interval for the mean is at most
+"target" wide. Rolls are made in rounds, each sized from the deviation seen so
+far, so the analysis usually finishes in two or three rounds.
+
+At most "maxN" rolls are made, or any number if it is <= 0. If the context is
+done or "maxN" is reached before the target, the analysis so far is returned
+along with the context's error or ErrPrecisionNotReached. An error is returned
+without an analysis if the target is not positive, or if the definition is
+invalid or a roll fails, see AnalyzeContext().
+*/
+func (d *Definition) AnalyzeUntilPrecision(ctx context.Context, target float64, maxN, threads int) (*Analysis, error) {
+	if !(target > 0) {
+		return nil, fmt.Errorf("dice: precision target must be positive, got %v", target)
+	}
+
+	startTime := time.Now()
+	z := zScore(PrecisionConfidence)
+
+	// The most rolls to make, capped well below math.MaxInt so the running
+	// count can't overflow
+	limit := math.MaxInt / 2
+	if maxN > 0 {
+		limit = min(maxN, limit)
+	}
+
+	// Start with a modest round to get an estimate of the deviation
+	round := min(1024*max(threads, 1), limit)
+
+	A := newAnalysis()
+	finish := func(err error) (*Analysis, error) {
+		A.Duration = float64(time.Since(startTime)) / float64(time.Second)
+		return A, err
+	}
+
+	for {
+		R, err := d.AnalyzeContext(ctx, AnalyzeOptions{N: round, Threads: threads})
+		if R == nil {
+			return nil, err
+		}
+		A.merge(R)
+		A.summarize()
+		if err != nil {
+			return finish(err)
+		}
+
+		if 2*z*A.StandardError() <= target {
+			return finish(nil)
+		}
+		if A.N >= limit {
+			return finish(fmt.Errorf("dice: %w after %d rolls", ErrPrecisionNotReached, A.N))
+		}
+
+		// Aim for the number of rolls the current deviation needs, but never
+		// less than doubling, as the deviation is only an estimate. This is
+		// worked out as a float, as it can be far too large for an int.
+		needed := math.Pow(2*z*A.Deviation/target, 2)
+		next := math.Max(needed-float64(A.N), float64(A.N))
+		round = int(math.Min(next, float64(limit-A.N)))
+	}
+}
+
+/////////////
+// HELPERS //
+/////////////
+
+// The z-score of a two sided confidence interval, such as 1.96 for 0.95
+func zScore(confidence float64) float64 {
+	return math.Sqrt2 * math.Erfinv(confidence)
+}
+
+// The Wilson score interval for a proportion p from n trials
+func wilson(p, n, z float64) (low, high float64) {
+	if n == 0 {
+		return 0, 1
+	}
+	z2 := z * z
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := z / (1 + z2/n) * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
@@ -9,12 +9,21 @@ const (
 	// Base case rolls
 	ROLL_SIDES    = "sides"
 	ROLL_WEIGHTED = "weighted"
+	ROLL_CONSTANT = "constant"
+	ROLL_FACES    = "faces"
 
 	//
-	ROLL_MULTIPLE = "multiple"
+	ROLL_MULTIPLE     = "multiple"
+	ROLL_EXPLODE      = "explode"
+	ROLL_EXPLODE_POOL = "explodePool"
+	ROLL_REROLL       = "reroll"
 
 	//
-	ROLL_SKIP = "skip"
+	ROLL_SKIP    = "skip"
+	ROLL_OPPOSED = "opposed"
+
+	//
+	ROLL_CONDITIONAL = "conditional"
 
 	//////////////////
 	// AGGREGATIONS //
@@ -22,4 +31,62 @@ const (
 
 	AGGREGATE_SUM       = "sum"
 	AGGREGATE_SUM_INDEX = "sumIndex"
+	AGGREGATE_LAST      = "last"
+
+	AGGREGATE_KEEP_HIGHEST = "keepHighest"
+	AGGREGATE_KEEP_LOWEST  = "keepLowest"
+	AGGREGATE_DROP_HIGHEST = "dropHighest"
+	AGGREGATE_DROP_LOWEST  = "dropLowest"
+
+	AGGREGATE_COUNT = "count"
+
+	AGGREGATE_SUBTRACT = "subtract"
+	AGGREGATE_SCALE    = "scale"
+	AGGREGATE_DIVIDE   = "divide"
+	AGGREGATE_PRODUCT  = "product"
+
+	AGGREGATE_MAX    = "max"
+	AGGREGATE_MIN    = "min"
+	AGGREGATE_MEDIAN = "median"
+
+	AGGREGATE_OPPOSED = "opposed"
+
+	AGGREGATE_CANCEL = "cancel"
+
+	//////////
+	// TAGS //
+	//////////
+
+	TAG_CRITICAL = "critical"
+	TAG_FUMBLE   = "fumble"
+
+	///////////////////
+	// OPPOSED ROLLS //
+	///////////////////
+
+	OPPOSED_MARGIN  = "margin"
+	OPPOSED_OUTCOME = "outcome"
+
+	TIES_TIE      = "tie"
+	TIES_ATTACKER = "attacker"
+	TIES_DEFENDER = "defender"
+
+	//////////////
+	// ROUNDING //
+	//////////////
+
+	ROUND_DOWN    = "down"
+	ROUND_UP      = "up"
+	ROUND_NEAREST = "nearest"
+
+	////////////////
+	// CONDITIONS //
+	////////////////
+
+	COMPARE_EQUAL         = "="
+	COMPARE_NOT_EQUAL     = "!="
+	COMPARE_LESS          = "<"
+	COMPARE_LESS_EQUAL    = "<="
+	COMPARE_GREATER       = ">"
+	COMPARE_GREATER_EQUAL = ">="
 )
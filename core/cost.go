@@ -0,0 +1,68 @@
+package core
+
+import "math"
+
+/*
+The most base rolls, such as a single die, that one Roll() of the definition
+could make. Pools multiply the cost of their dice, and explosions and rerolls
+count every extra roll they are allowed, so "10d6!" with a depth of 100 costs
+1010 even though it usually rolls about 12 dice. This is meant for limiting
+untrusted definitions before rolling them. Very large costs are capped at
+math.MaxInt rather than overflowing.
+*/
+func (d *Definition) Cost() (int, error) {
+	if err := d.load(""); err != nil {
+		return 0, err
+	}
+	return d.cost(), nil
+}
+
+// Internal recursive function for calculating costs
+func (d *Definition) cost() int {
+	if len(d.Children) == 0 {
+		return 1
+	}
+
+	children := make([]int, len(d.Children))
+	for i, child := range d.Children {
+		children[i] = child.cost()
+	}
+
+	if roll, ok := d.roll.(CostRoll); ok {
+		return roll.Cost(children)
+	}
+
+	total := 0
+	for _, cost := range children {
+		total = AddCost(total, cost)
+	}
+	return total
+}
+
+// The number of nodes in the definition, including itself. Missing children,
+// such as a null in JSON, aren't counted, and are reported by Validate().
+func (d *Definition) Size() int {
+	size := 1
+	for _, child := range d.Children {
+		if child != nil {
+			size += child.Size()
+		}
+	}
+	return size
+}
+
+// Add two costs, capping the result at math.MaxInt.
+func AddCost(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// Multiply two costs, capping the result at math.MaxInt.
+func MulCost(a, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
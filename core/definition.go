@@ -1,6 +1,9 @@
 package core
 
-import "math/rand"
+import (
+	"errors"
+	"fmt"
+)
 
 /*
 The Definition struct is the base object for the entirity of the "dice" package.
@@ -10,7 +13,7 @@ files.
 type Definition struct {
 
 	// The source used for all random events that occur for this definition
-	source *rand.Rand `json:"-"`
+	source RNG `json:"-"`
 
 	// If the definition is a parent, it will have an array of child Definitions
 	// that are necessary to facilitate the roll
@@ -49,46 +52,133 @@ func (d *Definition) Copy() *Definition {
 	return newDefinition
 }
 
-// Internal function for managing definition loads
-func (d *Definition) tryLoad() {
+/*
+Check that every node of the definition can be loaded. This catches unknown
+roll and aggregation types, missing or ill-typed params and malformed trees,
+and returns a *DefinitionError describing the path to the offending node.
+*/
+func (d *Definition) Validate() error {
+	return d.load("")
+}
+
+// Load the definition and all of its children, reporting errors with the
+// path to the node that failed.
+func (d *Definition) load(path string) error {
 
 	// No need to load if the definition is already loaded
 	if d.loaded {
-		return
+		return nil
 	}
 
 	// Otherwise, load all children first
-	for _, child := range d.Children {
-		child.tryLoad()
+	for i, child := range d.Children {
+		childPath := fmt.Sprintf("children[%d]", i)
+		if path != "" {
+			childPath = path + "." + childPath
+		}
+		if child == nil {
+			return &DefinitionError{Path: childPath, Err: fmt.Errorf("%w: child is missing", ErrInvalidChildren)}
+		}
+		if err := child.load(childPath); err != nil {
+			return err
+		}
 	}
 
 	// Load the roll
-	d.roll = GetRollType(d.RollType)
-	d.roll.Load(d.RollParams)
+	roll, err := GetRollType(d.RollType)
+	if err != nil {
+		return &DefinitionError{Path: path, Err: err}
+	}
+	if err := roll.Load(d.RollParams); err != nil {
+		return &DefinitionError{Path: path, Err: fmt.Errorf("rollType %q: %w", d.RollType, err)}
+	}
+	if validator, ok := roll.(ChildValidator); ok {
+		if err := validator.ValidateChildren(len(d.Children)); err != nil {
+			return &DefinitionError{Path: path, Err: fmt.Errorf("rollType %q: %w", d.RollType, err)}
+		}
+	}
 
 	// Load the aggregation.
 	// Skip empty aggregations as base rolls don't use them
+	var aggregation Aggregation
 	if d.AggregationType != "" {
-		d.aggregation = GetAggregationType(d.AggregationType)
-		d.aggregation.Load(d.AggregationParams)
+		aggregation, err = GetAggregationType(d.AggregationType)
+		if err != nil {
+			return &DefinitionError{Path: path, Err: err}
+		}
+		if err := aggregation.Load(d.AggregationParams); err != nil {
+			return &DefinitionError{Path: path, Err: fmt.Errorf("aggregationType %q: %w", d.AggregationType, err)}
+		}
+	} else if len(d.Children) > 0 {
+		return &DefinitionError{Path: path, Err: fmt.Errorf("%w: missing aggregationType", ErrInvalidParams)}
+	}
+
+	// Make sure the aggregation can handle the rolls when the count is known
+	if counter, ok := roll.(ResultCounter); ok {
+		if validator, ok := aggregation.(ResultValidator); ok {
+			if err := validator.ValidateResults(counter.ResultCount(len(d.Children))); err != nil {
+				return &DefinitionError{Path: path, Err: fmt.Errorf("aggregationType %q: %w", d.AggregationType, err)}
+			}
+		}
 	}
 
 	// Set the loaded flag to skip this process in the future
+	d.roll = roll
+	d.aggregation = aggregation
 	d.loaded = true
+	return nil
 
 }
 
-// Perform the roll described by the definition, and return a result object
+// Perform the roll described by the definition, and return a result object.
+// Panics if the definition is invalid, use RollE() to get an error instead.
 func (d *Definition) Roll() *Result {
+	result, err := d.RollE()
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+/*
+Perform the roll described by the definition, returning an error instead of
+panicking if any part of the definition is invalid, or if a roll or
+aggregation fails part way through.
+*/
+func (d *Definition) RollE() (result *Result, err error) {
 
 	// Try to load the roll
-	d.tryLoad()
+	if err := d.load(""); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if e := rollError(d, recover()); e != nil {
+			result, err = nil, e
+		}
+	}()
 
-	result := d.roll.Roll(d.source, d.Children)
+	result = d.roll.Roll(d.source, d.Children)
 	if result.Base {
-		return result
+		return result, nil
 	}
 	d.aggregation.Aggregate(result)
-	return result
+	return result, nil
 
 }
+
+/*
+Turn a recovered panic from a roll or aggregation into an error wrapping
+ErrRollFailed, or nil if there was no panic. Children are rolled with Roll(),
+so a failure deep in the tree is re-panicked as an error on the way up, and is
+passed through unchanged.
+*/
+func rollError(d *Definition, r interface{}) error {
+	if r == nil {
+		return nil
+	}
+	if e, ok := r.(error); ok && errors.Is(e, ErrRollFailed) {
+		return e
+	}
+	return fmt.Errorf("dice: %s roll: %w: %v", d.RollType, ErrRollFailed, r)
+}
@@ -0,0 +1,406 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+/*
+The Distribution object stores the exact probability of every total a
+Definition can roll. It is the exact counterpart of the Analysis object, which
+only estimates these values by sampling.
+*/
+type Distribution struct {
+
+	// The probability of rolling each total. The probabilities sum to 1.
+	Probabilities map[int]float64
+
+	// The mean value of the distribution.
+	Mean float64
+
+	// The standard deviation of the distribution.
+	Deviation float64
+
+	// The standard deviation up and down
+	DeviationUp   float64
+	DeviationDown float64
+}
+
+/*
+Function for determine the probability of a roll being at least a value
+*/
+func (d *Distribution) AtLeast(N int) float64 {
+	atLeast := 0.0
+	for value, probability := range d.Probabilities {
+		if value >= N {
+			atLeast += probability
+		}
+	}
+	return atLeast
+}
+
+/*
+Function for determine the probability of a roll being at most a value
+*/
+func (d *Distribution) AtMost(N int) float64 {
+	return 1.0 - d.AtLeast(N+1)
+}
+
+// Returns every total with a non-zero probability in ascending order
+func (d *Distribution) Values() []int {
+	values := make([]int, 0, len(d.Probabilities))
+	for value := range d.Probabilities {
+		values = append(values, value)
+	}
+	sort.Ints(values)
+	return values
+}
+
+// Create a new *Distribution from a map of probabilities and fill out the
+// summary statistics.
+func NewDistribution(probabilities map[int]float64) *Distribution {
+	d := &Distribution{Probabilities: probabilities}
+	d.Mean, d.Deviation, d.DeviationUp, d.DeviationDown = moments(probabilities)
+	return d
+}
+
+/*
+Calculates the mean and standard deviations for a map of values to weights.
+The weights do not need to be normalized, so this works for both roll counts
+and probabilities. The up and down deviations use the same weighting as the
+"Analysis" object.
+*/
+func moments[T int | float64](weights map[int]T) (mean, deviation, deviationUp, deviationDown float64) {
+
+	// Sum in a fixed order so the same weights always give the same result
+	values := make([]int, 0, len(weights))
+	for value := range weights {
+		values = append(values, value)
+	}
+	sort.Ints(values)
+
+	total := 0.0
+	for _, value := range values {
+		mean += float64(value) * float64(weights[value])
+		total += float64(weights[value])
+	}
+	mean /= total
+
+	variance := 0.0
+	varianceUp := 0.0
+	upCount := 0.0
+	varianceDown := 0.0
+	downCount := 0.0
+	for _, value := range values {
+		weight := weights[value]
+		d := float64(weight) * math.Pow(mean-float64(value), 2)
+		variance += d
+
+		percent := (math.Tanh(float64(value)-mean) + 1.0) / 2.0
+
+		upCount += float64(weight) * percent
+		downCount += float64(weight) * (1.0 - percent)
+		varianceUp += d * percent
+		varianceDown += d * (1.0 - percent)
+	}
+
+	deviation = math.Pow(variance/total, 0.5)
+	deviationUp = math.Pow(varianceUp/upCount, 0.5)
+	deviationDown = math.Pow(varianceDown/downCount, 0.5)
+	return
+}
+
+// Create a new *Distribution by applying a function to every total
+func (d *Distribution) Map(f func(int) int) *Distribution {
+	probabilities := map[int]float64{}
+	for value, probability := range d.Probabilities {
+		probabilities[f(value)] += probability
+	}
+	return NewDistribution(probabilities)
+}
+
+/*
+Combine two independent distributions by adding their totals together.
+*/
+func Convolve(a, b *Distribution) *Distribution {
+	probabilities := map[int]float64{}
+	for x, px := range a.Probabilities {
+		for y, py := range b.Probabilities {
+			probabilities[x+y] += px * py
+		}
+	}
+	return NewDistribution(probabilities)
+}
+
+////////////////
+// INTERFACES //
+////////////////
+
+/*
+A single possible Result of a Roll, before it is aggregated, along with the
+probability of it occurring.
+*/
+type Outcome struct {
+	Probability float64
+	Result      *Result
+}
+
+/*
+Rolls which can enumerate every possible Result they produce implement
+ExactRoll. The distributions of the Roll's children are passed in the same
+order as the children themselves.
+*/
+type ExactRoll interface {
+	Outcomes(children []*Distribution) ([]*Outcome, error)
+}
+
+/*
+Rolls whose sub-results are independent of each other implement
+IndependentRoll, returning the distribution of each sub-result in order.
+Returning the same *Distribution several times marks those parts as identical,
+which is much cheaper to enumerate.
+*/
+type IndependentRoll interface {
+	Parts(children []*Distribution) []*Distribution
+}
+
+/*
+Aggregations which can combine independent parts directly implement
+ExactAggregation. This is used with IndependentRoll to skip enumerating every
+outcome, which is what keeps things like "100d6" fast.
+*/
+type ExactAggregation interface {
+	Combine(parts []*Distribution) *Distribution
+}
+
+/*
+Aggregations which read the symbols of their rolls, rather than only the
+totals, implement SymbolAggregation. Distributions only track totals, so
+Distribution() returns an error for these.
+*/
+type SymbolAggregation interface {
+	ReadsSymbols() bool
+}
+
+/*
+Rolls which make a varying number of independent rolls, like an exploding
+pool, implement PoolRoll. PoolSum returns the distribution of "worth" summed
+over every roll the pool makes, where "worth" gives what a single roll with
+that total is worth to the aggregation. This is used for AdditiveAggregations
+instead of enumerating every outcome.
+*/
+type PoolRoll interface {
+	PoolSum(children []*Distribution, worth func(total int) int) *Distribution
+}
+
+/*
+Aggregations whose total is the sum of what each roll is worth on its own,
+like summing or counting successes, implement AdditiveAggregation.
+*/
+type AdditiveAggregation interface {
+	Additive() bool
+}
+
+/*
+The maximum number of outcomes that will be enumerated for a single node of a
+Definition before Distribution() gives up and returns an error.
+*/
+var MaxOutcomes = 1 << 20
+
+////////////
+// ENGINE //
+////////////
+
+/*
+Calculate the exact distribution of totals for the definition. Every node of
+the definition must use a Roll that implements ExactRoll or IndependentRoll.
+*/
+func (d *Definition) Distribution() (distribution *Distribution, err error) {
+
+	// Try to load the roll
+	if err := d.load(""); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if e := rollError(d, recover()); e != nil {
+			distribution, err = nil, e
+		}
+	}()
+	return d.distribution()
+}
+
+// Internal recursive function for calculating distributions
+func (d *Definition) distribution() (*Distribution, error) {
+
+	// Calculate the distribution of all the children first
+	children := make([]*Distribution, len(d.Children))
+	for i, child := range d.Children {
+		distribution, err := child.distribution()
+		if err != nil {
+			return nil, err
+		}
+		children[i] = distribution
+	}
+
+	if aggregation, ok := d.aggregation.(SymbolAggregation); ok && aggregation.ReadsSymbols() {
+		return nil, fmt.Errorf("dice: aggregation type %s does not support exact distributions", d.AggregationType)
+	}
+
+	// Pools can be summed without enumerating them if the aggregation only
+	// adds up what each roll is worth
+	if roll, ok := d.roll.(PoolRoll); ok {
+		if aggregation, ok := d.aggregation.(AdditiveAggregation); ok && aggregation.Additive() {
+			worth := func(total int) int {
+				result := newParentResult([]int{total})
+				d.aggregation.Aggregate(result)
+				return result.Total
+			}
+			return roll.PoolSum(children, worth), nil
+		}
+	}
+
+	// Independent rolls can either be combined directly, or enumerated
+	if roll, ok := d.roll.(IndependentRoll); ok {
+		parts := roll.Parts(children)
+		if aggregation, ok := d.aggregation.(ExactAggregation); ok {
+			return aggregation.Combine(parts), nil
+		}
+
+		outcomes, err := enumerate(parts)
+		if err != nil {
+			return nil, fmt.Errorf("dice: %s roll: %w", d.RollType, err)
+		}
+		return d.aggregate(outcomes), nil
+	}
+
+	if roll, ok := d.roll.(ExactRoll); ok {
+		outcomes, err := roll.Outcomes(children)
+		if err != nil {
+			return nil, fmt.Errorf("dice: %s roll: %w", d.RollType, err)
+		}
+		return d.aggregate(outcomes), nil
+	}
+
+	return nil, fmt.Errorf("dice: roll type %s does not support exact distributions", d.RollType)
+}
+
+// Run the aggregation over every outcome and collect the totals
+func (d *Definition) aggregate(outcomes []*Outcome) *Distribution {
+	probabilities := map[int]float64{}
+	for _, outcome := range outcomes {
+		if !outcome.Result.Base {
+			d.aggregation.Aggregate(outcome.Result)
+		}
+		probabilities[outcome.Result.Total] += outcome.Probability
+	}
+	return NewDistribution(probabilities)
+}
+
+/*
+Enumerate every combination of independent parts as an unaggregated Result.
+If every part is the same distribution, only the sorted combinations are
+enumerated, weighted by the number of orderings they represent.
+*/
+func enumerate(parts []*Distribution) ([]*Outcome, error) {
+
+	identical := len(parts) > 0
+	for _, part := range parts {
+		identical = identical && part == parts[0]
+	}
+
+	if identical {
+		values := parts[0].Values()
+		if binomial(len(values)+len(parts)-1, len(parts)) > float64(MaxOutcomes) {
+			return nil, fmt.Errorf("more than %d outcomes to enumerate", MaxOutcomes)
+		}
+
+		outcomes := []*Outcome{}
+		enumerateIdentical(parts[0], values, len(parts), 1.0, []int{}, &outcomes)
+		return outcomes, nil
+	}
+
+	count := 1.0
+	for _, part := range parts {
+		count *= float64(len(part.Probabilities))
+	}
+	if count > float64(MaxOutcomes) {
+		return nil, fmt.Errorf("more than %d outcomes to enumerate", MaxOutcomes)
+	}
+
+	outcomes := []*Outcome{}
+	enumerateProduct(parts, 1.0, []int{}, &outcomes)
+	return outcomes, nil
+}
+
+// Recursively choose how many of the remaining parts roll each value
+func enumerateIdentical(part *Distribution, values []int, remaining int, probability float64, totals []int, outcomes *[]*Outcome) {
+	if remaining == 0 || len(values) == 1 {
+		p := part.Probabilities[values[0]]
+		for i := 0; i < remaining; i++ {
+			totals = append(totals, values[0])
+		}
+		*outcomes = append(*outcomes, &Outcome{
+			Probability: probability * math.Pow(p, float64(remaining)),
+			Result:      newParentResult(totals),
+		})
+		return
+	}
+
+	p := part.Probabilities[values[0]]
+	for count := 0; count <= remaining; count++ {
+		next := append([]int{}, totals...)
+		for i := 0; i < count; i++ {
+			next = append(next, values[0])
+		}
+		weight := binomial(remaining, count) * math.Pow(p, float64(count))
+		enumerateIdentical(part, values[1:], remaining-count, probability*weight, next, outcomes)
+	}
+}
+
+// Recursively choose the value for each part in order
+func enumerateProduct(parts []*Distribution, probability float64, totals []int, outcomes *[]*Outcome) {
+	if len(parts) == 0 {
+		*outcomes = append(*outcomes, &Outcome{
+			Probability: probability,
+			Result:      newParentResult(totals),
+		})
+		return
+	}
+
+	for _, value := range parts[0].Values() {
+		next := append(append([]int{}, totals...), value)
+		enumerateProduct(parts[1:], probability*parts[0].Probabilities[value], next, outcomes)
+	}
+}
+
+// Create an unaggregated parent result with a base result for each total
+func newParentResult(totals []int) *Result {
+	result := &Result{
+		Base:    false,
+		Results: []*Result{},
+		Values:  []int{},
+		Total:   0,
+	}
+	for _, total := range totals {
+		result.Results = append(result.Results, &Result{
+			Base:   true,
+			Values: []int{total},
+			Total:  total,
+		})
+	}
+	return result
+}
+
+// The binomial coefficient n choose k, as a float to avoid overflow
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result *= float64(n-k+i) / float64(i)
+	}
+	return result
+}
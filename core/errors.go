@@ -0,0 +1,45 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors which can be matched with errors.Is() on anything returned while
+// loading or validating a Definition.
+var (
+	ErrUnknownRollType        = errors.New("unknown roll type")
+	ErrUnknownAggregationType = errors.New("unknown aggregation type")
+	ErrInvalidParams          = errors.New("invalid params")
+	ErrInvalidChildren        = errors.New("invalid children")
+)
+
+// Error returned by RollE() and Distribution() when a roll or aggregation
+// fails part way through, such as an index that is out of range for the rolls
+// that were actually made.
+var ErrRollFailed = errors.New("roll failed")
+
+// Error returned by AnalyzeUntilPrecision() when it reaches its maximum number
+// of rolls before the mean is precise enough.
+var ErrPrecisionNotReached = errors.New("precision not reached")
+
+/*
+DefinitionError is returned when a node of a Definition fails to load. Path
+describes where the node is in the tree, such as "children[1].children[0]", and
+is empty for the root node.
+*/
+type DefinitionError struct {
+	Path string
+	Err  error
+}
+
+func (e *DefinitionError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("dice: definition: %v", e.Err)
+	}
+	return fmt.Sprintf("dice: definition %s: %v", e.Path, e.Err)
+}
+
+func (e *DefinitionError) Unwrap() error {
+	return e.Err
+}
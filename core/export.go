@@ -0,0 +1,373 @@
+package core
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+A single row of an exported analysis. Probabilities are between 0 and 1, and
+"AtLeast" and "AtMost" include the value itself.
+*/
+type ExportRow struct {
+	Value       int     `json:"value"`
+	Count       int     `json:"count"`
+	Probability float64 `json:"probability"`
+	AtLeast     float64 `json:"atLeast"`
+	AtMost      float64 `json:"atMost"`
+}
+
+// The rows of the analysis, one for every rolled value in ascending order.
+func (a *Analysis) Rows() []ExportRow {
+	return a.rowsFor(a.values())
+}
+
+/*
+The rows of the analysis for the given values in ascending order, which don't
+need to have been rolled. The rolls are sorted once and counted in a single
+pass, so this stays fast for analyses with many distinct values.
+*/
+func (a *Analysis) rowsFor(values []int) []ExportRow {
+	rolled := a.values()
+	rows := make([]ExportRow, len(values))
+
+	// The number of rolls below the current value, and the index of the first
+	// rolled value that hasn't been counted yet
+	below, next := 0, 0
+	for i, value := range values {
+		for next < len(rolled) && rolled[next] < value {
+			below += a.Rolls[rolled[next]]
+			next++
+		}
+		count := a.Rolls[value]
+
+		rows[i] = ExportRow{
+			Value:       value,
+			Count:       count,
+			Probability: ratio(count, a.N),
+			AtLeast:     ratio(a.N-below, a.N),
+			AtMost:      ratio(below+count, a.N),
+		}
+	}
+	return rows
+}
+
+/////////
+// CSV //
+/////////
+
+/*
+Write the analysis as CSV with a header row and the columns value, count,
+probability, atLeast and atMost. Probabilities are written between 0 and 1 so
+spreadsheets can format them as they like.
+*/
+func (a *Analysis) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"value", "count", "probability", "atLeast", "atMost"})
+	for _, row := range a.Rows() {
+		writer.Write([]string{
+			strconv.Itoa(row.Value),
+			strconv.Itoa(row.Count),
+			formatProbability(row.Probability),
+			formatProbability(row.AtLeast),
+			formatProbability(row.AtMost),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+//////////////
+// MARKDOWN //
+//////////////
+
+/*
+Write the analysis as a Markdown table with the same columns as WriteCSV,
+with the probabilities written as percentages.
+*/
+func (a *Analysis) WriteMarkdown(w io.Writer) error {
+	lines := []string{
+		"| Value | Count | Probability | At least | At most |",
+		"| ---: | ---: | ---: | ---: | ---: |",
+	}
+	for _, row := range a.Rows() {
+		lines = append(lines, fmt.Sprintf("| %d | %d | %s | %s | %s |",
+			row.Value, row.Count,
+			formatPercent(row.Probability),
+			formatPercent(row.AtLeast),
+			formatPercent(row.AtMost)))
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+//////////
+// JSON //
+//////////
+
+/*
+The stable JSON shape of an Analysis. The rolls are a list of rows sorted by
+value, rather than a map, so the output is the same every time and doesn't
+depend on how encoding/json orders integer keys.
+*/
+type analysisJSON struct {
+	N             int     `json:"n"`
+	Mean          float64 `json:"mean"`
+	Deviation     float64 `json:"deviation"`
+	DeviationUp   float64 `json:"deviationUp"`
+	DeviationDown float64 `json:"deviationDown"`
+	Min           int     `json:"min"`
+	Max           int     `json:"max"`
+	Median        int     `json:"median"`
+	Mode          int     `json:"mode"`
+	Duration      float64 `json:"duration"`
+
+	Rolls   []ExportRow    `json:"rolls"`
+	Tags    map[string]int `json:"tags,omitempty"`
+	Symbols map[string]int `json:"symbols,omitempty"`
+}
+
+/*
+Encode the analysis with its summary statistics and its rolls sorted by value.
+The statistics are only informative, decoding the JSON recalculates them from
+the rolls.
+*/
+func (a *Analysis) MarshalJSON() ([]byte, error) {
+	encoded := &analysisJSON{
+		N:             a.N,
+		Mean:          finite(a.Mean),
+		Deviation:     finite(a.Deviation),
+		DeviationUp:   finite(a.DeviationUp),
+		DeviationDown: finite(a.DeviationDown),
+		Duration:      a.Duration,
+		Rolls:         a.Rows(),
+		Tags:          nonEmpty(a.Tags),
+		Symbols:       nonEmpty(a.Symbols),
+	}
+
+	// Work out the order statistics from the sorted rows, rather than sorting
+	// the rolls again for each of them
+	if rows := encoded.Rolls; len(rows) > 0 {
+		encoded.Min = rows[0].Value
+		encoded.Max = rows[len(rows)-1].Value
+		encoded.Median = encoded.Max
+
+		rank := quantileRank(0.5, a.N)
+		median := false
+		cumulative, mode := 0, 0
+		for _, row := range rows {
+			cumulative += row.Count
+			if !median && cumulative >= rank {
+				encoded.Median = row.Value
+				median = true
+			}
+			if row.Count > mode {
+				encoded.Mode = row.Value
+				mode = row.Count
+			}
+		}
+	}
+
+	return json.Marshal(encoded)
+}
+
+// Decode an analysis encoded by MarshalJSON.
+func (a *Analysis) UnmarshalJSON(data []byte) error {
+	decoded := analysisJSON{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*a = *newAnalysis()
+	for _, row := range decoded.Rolls {
+		a.Rolls[row.Value] += row.Count
+	}
+	for tag, count := range decoded.Tags {
+		a.Tags[tag] = count
+	}
+	for key, count := range decoded.Symbols {
+		a.Symbols[key] = count
+	}
+	a.summarize()
+	a.Duration = decoded.Duration
+	return nil
+}
+
+///////////
+// TABLE //
+///////////
+
+/*
+A Table lines up several analyses by value, such as the same attack with and
+without advantage, so they can be exported side by side. "Labels" names each
+analysis in the column headers.
+*/
+type Table struct {
+	Labels   []string
+	Analyses []*Analysis
+}
+
+// Every value rolled by any of the analyses, in ascending order
+func (t *Table) values() []int {
+	seen := map[int]bool{}
+	for _, A := range t.Analyses {
+		for value := range A.Rolls {
+			seen[value] = true
+		}
+	}
+	values := make([]int, 0, len(seen))
+	for value := range seen {
+		values = append(values, value)
+	}
+	sort.Ints(values)
+	return values
+}
+
+// The label of the ith analysis, falling back to its position
+func (t *Table) label(i int) string {
+	if i < len(t.Labels) && t.Labels[i] != "" {
+		return t.Labels[i]
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// The rows of every analysis over the same values
+func (t *Table) rows() ([]int, [][]ExportRow) {
+	values := t.values()
+	rows := make([][]ExportRow, len(t.Analyses))
+	for i, A := range t.Analyses {
+		rows[i] = A.rowsFor(values)
+	}
+	return values, rows
+}
+
+/*
+Write the table as CSV. The first column is the value, followed by the count,
+probability, atLeast and atMost of each analysis, with headers like
+"2d20kh count".
+*/
+func (t *Table) WriteCSV(w io.Writer) error {
+	values, rows := t.rows()
+
+	header := []string{"value"}
+	for i := range t.Analyses {
+		for _, column := range []string{"count", "probability", "atLeast", "atMost"} {
+			header = append(header, t.label(i)+" "+column)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write(header)
+	for j, value := range values {
+		record := []string{strconv.Itoa(value)}
+		for i := range t.Analyses {
+			row := rows[i][j]
+			record = append(record,
+				strconv.Itoa(row.Count),
+				formatProbability(row.Probability),
+				formatProbability(row.AtLeast),
+				formatProbability(row.AtMost))
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+/*
+Write the table as Markdown, with the same columns as WriteCSV and the
+probabilities written as percentages.
+*/
+func (t *Table) WriteMarkdown(w io.Writer) error {
+	values, rows := t.rows()
+
+	header := "| Value |"
+	align := "| ---: |"
+	for i := range t.Analyses {
+		label := strings.ReplaceAll(t.label(i), "|", "\\|")
+		for _, column := range []string{"count", "probability", "at least", "at most"} {
+			header += fmt.Sprintf(" %s %s |", label, column)
+			align += " ---: |"
+		}
+	}
+
+	lines := []string{header, align}
+	for j, value := range values {
+		line := fmt.Sprintf("| %d |", value)
+		for i := range t.Analyses {
+			row := rows[i][j]
+			line += fmt.Sprintf(" %d | %s | %s | %s |", row.Count,
+				formatPercent(row.Probability),
+				formatPercent(row.AtLeast),
+				formatPercent(row.AtMost))
+		}
+		lines = append(lines, line)
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// The JSON shape of one analysis in a table
+type tableColumnJSON struct {
+	Label    string    `json:"label"`
+	Analysis *Analysis `json:"analysis"`
+}
+
+/*
+Encode the table as {"values": [...], "columns": [{"label", "analysis"}]},
+where each analysis has the same shape as Analysis.MarshalJSON().
+*/
+func (t *Table) MarshalJSON() ([]byte, error) {
+	columns := make([]tableColumnJSON, len(t.Analyses))
+	for i, A := range t.Analyses {
+		columns[i] = tableColumnJSON{Label: t.label(i), Analysis: A}
+	}
+	return json.Marshal(struct {
+		Values  []int             `json:"values"`
+		Columns []tableColumnJSON `json:"columns"`
+	}{t.values(), columns})
+}
+
+/////////////
+// HELPERS //
+/////////////
+
+// A count as a fraction of a total, or 0 for an empty total
+func ratio(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
+
+// Format a probability between 0 and 1 for CSV
+func formatProbability(p float64) string {
+	return strconv.FormatFloat(p, 'f', 6, 64)
+}
+
+// Format a probability between 0 and 1 as a percentage for Markdown
+func formatPercent(p float64) string {
+	return fmt.Sprintf("%.2f%%", p*100)
+}
+
+// JSON can't encode NaN or infinities, which empty analyses produce
+func finite(x float64) float64 {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return 0
+	}
+	return x
+}
+
+// Nil for empty maps, so they are left out of the JSON
+func nonEmpty(m map[string]int) map[string]int {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
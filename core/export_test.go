@@ -0,0 +1,66 @@
+package core
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// An analysis with random counts over a spread of values
+func randomAnalysis(values int, seed int64) *Analysis {
+	source := rand.New(rand.NewSource(seed))
+	A := newAnalysis()
+	for i := 0; i < values; i++ {
+		A.Rolls[source.Intn(4*values)-values] += 1 + source.Intn(10)
+	}
+	A.summarize()
+	return A
+}
+
+func TestRows(t *testing.T) {
+	A := randomAnalysis(200, 1)
+	for _, row := range A.Rows() {
+		atLeast, atMost := 0, 0
+		for value, count := range A.Rolls {
+			if value >= row.Value {
+				atLeast += count
+			}
+			if value <= row.Value {
+				atMost += count
+			}
+		}
+		if row.Count != A.Rolls[row.Value] || row.AtLeast != ratio(atLeast, A.N) || row.AtMost != ratio(atMost, A.N) {
+			t.Errorf("row %+v, want count %d, atLeast %v, atMost %v", row, A.Rolls[row.Value], ratio(atLeast, A.N), ratio(atMost, A.N))
+		}
+	}
+}
+
+func TestAnalysisJSON(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		A := randomAnalysis(50, seed)
+		A.Tags[TAG_CRITICAL] = 3
+
+		data, err := json.Marshal(A)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		summary := analysisJSON{}
+		if err := json.Unmarshal(data, &summary); err != nil {
+			t.Fatal(err)
+		}
+		if summary.Min != A.Min() || summary.Max != A.Max() || summary.Median != A.Median() || summary.Mode != A.Mode() {
+			t.Errorf("seed %d: got min %d, max %d, median %d, mode %d, want %d, %d, %d, %d", seed,
+				summary.Min, summary.Max, summary.Median, summary.Mode, A.Min(), A.Max(), A.Median(), A.Mode())
+		}
+
+		decoded := &Analysis{}
+		if err := json.Unmarshal(data, decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, A) {
+			t.Errorf("seed %d: decoded analysis differs from the original", seed)
+		}
+	}
+}
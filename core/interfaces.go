@@ -3,7 +3,6 @@ package core
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"sync"
 )
 
@@ -18,8 +17,46 @@ var lock = sync.Mutex{}
 //////////
 
 type Roll interface {
-	Load(params map[string]interface{})
-	Roll(*rand.Rand, []*Definition) *Result
+	Load(params map[string]interface{}) error
+	Roll(RNG, []*Definition) *Result
+}
+
+/*
+Rolls which need a specific number of children can implement ChildValidator
+so that bad trees are caught when the Definition is loaded, rather than
+panicking in the middle of a roll.
+*/
+type ChildValidator interface {
+	ValidateChildren(count int) error
+}
+
+/*
+Rolls which always produce the same number of sub-results implement
+ResultCounter, given the number of children the definition has. This lets
+aggregations check they will have the rolls they need when the Definition is
+loaded.
+*/
+type ResultCounter interface {
+	ResultCount(children int) int
+}
+
+/*
+Aggregations which only work with certain numbers of sub-results implement
+ResultValidator. It is only checked when the roll implements ResultCounter, so
+a bad count from any other roll is reported by RollE() when it happens.
+*/
+type ResultValidator interface {
+	ValidateResults(count int) error
+}
+
+/*
+Rolls which roll their children more than once, or only some of them,
+implement CostRoll. Given the cost of each child, it returns the most base
+rolls a single Roll() could make. Rolls without it cost the sum of their
+children. See Definition.Cost().
+*/
+type CostRoll interface {
+	Cost(children []int) int
 }
 
 var rollTypes = map[string]func() Roll{}
@@ -30,14 +67,14 @@ func AddRollType(rollType string, newFunction func() Roll) {
 	rollTypes[rollType] = newFunction
 }
 
-func GetRollType(rollType string) Roll {
+func GetRollType(rollType string) (Roll, error) {
 	lock.Lock()
 	defer lock.Unlock()
 	rollFunction, ok := rollTypes[rollType]
 	if !ok {
-		panic(fmt.Sprintf("Invalid rollType %s provided to GetRollType", rollType))
+		return nil, fmt.Errorf("%w %q", ErrUnknownRollType, rollType)
 	}
-	return rollFunction()
+	return rollFunction(), nil
 }
 
 //////////////////
@@ -45,7 +82,7 @@ func GetRollType(rollType string) Roll {
 //////////////////
 
 type Aggregation interface {
-	Load(params map[string]interface{})
+	Load(params map[string]interface{}) error
 	Aggregate(*Result)
 }
 
@@ -57,14 +94,14 @@ func AddAggregationType(aggregationType string, newFunction func() Aggregation)
 	aggregationTypes[aggregationType] = newFunction
 }
 
-func GetAggregationType(aggregationType string) Aggregation {
+func GetAggregationType(aggregationType string) (Aggregation, error) {
 	lock.Lock()
 	defer lock.Unlock()
 	aggregationFunction, ok := aggregationTypes[aggregationType]
 	if !ok {
-		panic(fmt.Sprintf("Invalid aggregationType %s provided to GetAggregationType", aggregationType))
+		return nil, fmt.Errorf("%w %q", ErrUnknownAggregationType, aggregationType)
 	}
-	return aggregationFunction()
+	return aggregationFunction(), nil
 }
 
 /*
@@ -72,18 +109,21 @@ Helper function to be used in Roll and Aggregation interface structs.
 Spcifically it makes the Load(map[string]interface{}) method pretty trivial
 and allows all load functions to look like this:
 
-	func (s *interfaceStruct) Load(params map[string]interface{}) {
-		core.SetParams(s, params)
+	func (s *interfaceStruct) Load(params map[string]interface{}) error {
+		return core.SetParams(s, params)
 	}
+
+Any errors are wrapped with ErrInvalidParams.
 */
-func SetParams[T any](c *T, params map[string]interface{}) {
+func SetParams[T any](c *T, params map[string]interface{}) error {
 	paramsBytes, err := json.Marshal(params)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("%w: %v", ErrInvalidParams, err)
 	}
 
 	err = json.Unmarshal(paramsBytes, c)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("%w: %v", ErrInvalidParams, err)
 	}
+	return nil
 }
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+/*
+Decode a Definition from the same JSON shape it is encoded to. Params are
+decoded through the struct of their registered Roll or Aggregation, so they
+come back with the types they were created with, such as the map[int]int of
+"weights" or the Condition of a reroll, and a decoded Definition matches the
+one that was originally encoded. Params of unknown types, or that their type
+can't decode, fall back to plain JSON values with numbers as ints where
+possible, and are reported by Validate().
+
+Unknown keys are an error, including keys that only differ in case, such as
+"rolltype", which encoding/json would otherwise accept. The default source is
+attached to the decoded Definition. A null child is an error wrapping
+ErrInvalidChildren.
+*/
+func (d *Definition) UnmarshalJSON(data []byte) error {
+
+	// Check the keys exactly before decoding, as encoding/json matches them
+	// case insensitively and drops any it doesn't know
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for key := range fields {
+		if !definitionFields[key] {
+			return fmt.Errorf("dice: definition: unknown field %q", key)
+		}
+	}
+
+	// Decode into a type without this method to avoid recursing forever
+	type definition Definition
+	decoded := definition{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return err
+	}
+
+	*d = Definition{
+		Children:          decoded.Children,
+		RollType:          decoded.RollType,
+		RollParams:        normalizeParams(decoded.RollParams),
+		AggregationType:   decoded.AggregationType,
+		AggregationParams: normalizeParams(decoded.AggregationParams),
+	}
+	if roll, err := GetRollType(d.RollType); err == nil {
+		typeParams(d.RollParams, roll)
+	}
+	if aggregation, err := GetAggregationType(d.AggregationType); err == nil {
+		typeParams(d.AggregationParams, aggregation)
+	}
+	if d.Children == nil {
+		d.Children = []*Definition{}
+	}
+	for i, child := range d.Children {
+		if child == nil {
+			return fmt.Errorf("dice: definition: %w: child %d is null", ErrInvalidChildren, i)
+		}
+	}
+
+	d.SetDefaultSource()
+	return nil
+}
+
+// The keys a Definition is encoded with
+var definitionFields = map[string]bool{
+	"children":          true,
+	"rollType":          true,
+	"rollParams":        true,
+	"aggregationType":   true,
+	"aggregationParams": true,
+}
+
+/*
+Replace each param with the value of the matching field of "target", a pointer
+to a Roll or Aggregation struct, after decoding the params into it. Pointer
+fields, like the optional "critical" of a die, are stored as the value they
+point to. Nothing is changed if the params can't be decoded.
+*/
+func typeParams(params map[string]interface{}, target interface{}) {
+	v := reflect.ValueOf(target)
+	if len(params) == 0 || v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	data, err := json.Marshal(params)
+	if err != nil || json.Unmarshal(data, target) != nil {
+		return
+	}
+
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if value, ok := params[name]; !ok || value == nil {
+			continue
+		}
+
+		value := v.Field(i)
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+		params[name] = value.Interface()
+	}
+}
+
+// Convert decoded params so every json.Number becomes an int, or a float64 if
+// it is not a whole number.
+func normalizeParams(params map[string]interface{}) map[string]interface{} {
+	if params == nil {
+		return nil
+	}
+	normalized := map[string]interface{}{}
+	for key, value := range params {
+		normalized[key] = normalizeValue(value)
+	}
+	return normalized
+}
+
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil && i >= math.MinInt && i <= math.MaxInt {
+			return int(i)
+		}
+		f, _ := v.Float64()
+		return f
+
+	case map[string]interface{}:
+		return normalizeParams(v)
+
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeValue(item)
+		}
+		return normalized
+	}
+	return value
+}
@@ -30,4 +30,64 @@ type Result struct {
 		rolls.
 	*/
 	Total int `json:"total"`
+
+	/*
+		Counts of tags such as TAG_CRITICAL, set by base rolls and passed up by
+		aggregations from the rolls that count towards the total. This is nil
+		when there are no tags.
+	*/
+	Tags map[string]int `json:"tags,omitempty"`
+
+	/*
+		Totals of named symbols, such as the successes and advantages on
+		narrative dice, set by dice with faces and passed up by aggregations
+		alongside the tags. This is nil when there are no symbols.
+	*/
+	Symbols map[string]int `json:"symbols,omitempty"`
+}
+
+/*
+A single face of a die created with dice.NewFaces(). Each face has an integer
+value, which counts towards the total, and any number of named symbols.
+*/
+type Face struct {
+	Value   int            `json:"value"`
+	Symbols map[string]int `json:"symbols,omitempty"`
+}
+
+// Add to the count of a tag on the result.
+func (r *Result) AddTag(tag string, count int) {
+	if r.Tags == nil {
+		r.Tags = map[string]int{}
+	}
+	r.Tags[tag] += count
+}
+
+// Check whether the result has a tag.
+func (r *Result) HasTag(tag string) bool {
+	return r.Tags[tag] > 0
+}
+
+// Add to the total of a symbol on the result.
+func (r *Result) AddSymbol(symbol string, count int) {
+	if r.Symbols == nil {
+		r.Symbols = map[string]int{}
+	}
+	r.Symbols[symbol] += count
+}
+
+/*
+Add the tags and symbols of each of the sub-results onto this result.
+Aggregations call this for the rolls that count towards the total, so that a
+natural 20 dropped by disadvantage doesn't make the whole roll a critical.
+*/
+func (r *Result) Inherit(results ...*Result) {
+	for _, result := range results {
+		for tag, count := range result.Tags {
+			r.AddTag(tag, count)
+		}
+		for symbol, count := range result.Symbols {
+			r.AddSymbol(symbol, count)
+		}
+	}
 }
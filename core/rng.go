@@ -0,0 +1,78 @@
+package core
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+	randv2 "math/rand/v2"
+)
+
+/*
+RNG is the source of randomness for rolls. Intn returns a uniformly random
+number in [0, n), and may panic if n <= 0.
+
+A *math/rand.Rand is already an RNG. The adapters below cover the generators
+of math/rand/v2 and crypto/rand, and anything else only needs an Intn method.
+Like *math/rand.Rand, most RNGs are not safe for concurrent use, so each
+goroutine should roll with its own. CryptoRNG is the exception.
+*/
+type RNG interface {
+	Intn(n int) int
+}
+
+// Create a math/rand generator from a seed. This is what SetSeed() uses.
+func NewMathRand(seed int64) RNG {
+	return rand.New(rand.NewSource(seed))
+}
+
+/*
+Create a math/rand/v2 PCG generator from a seed. PCG is faster than math/rand
+and a good choice for large analyses, see "AnalyzeOptions.NewRNG".
+*/
+func NewPCG(seed int64) RNG {
+	return FromRandV2(randv2.New(randv2.NewPCG(uint64(seed), uint64(deriveSeed(seed, 0)))))
+}
+
+/*
+Create a math/rand/v2 ChaCha8 generator from a seed. ChaCha8 is a
+cryptographically strong generator that is still reproducible from its seed.
+*/
+func NewChaCha8(seed int64) RNG {
+	var key [32]byte
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint64(key[i*8:], uint64(deriveSeed(seed, i)))
+	}
+	return FromRandV2(randv2.New(randv2.NewChaCha8(key)))
+}
+
+// Use any *math/rand/v2.Rand as an RNG.
+func FromRandV2(r *randv2.Rand) RNG {
+	return &randV2{r}
+}
+
+type randV2 struct {
+	r *randv2.Rand
+}
+
+func (r *randV2) Intn(n int) int {
+	return r.r.IntN(n)
+}
+
+/*
+An RNG reading from crypto/rand, for rolls that players need to be able to
+trust, such as rolling on their behalf online. It can't be seeded, is much
+slower than the other generators, and is safe for concurrent use.
+*/
+var CryptoRNG RNG = FromRandV2(randv2.New(cryptoSource{}))
+
+// A math/rand/v2 source reading from crypto/rand. It has no state, so it is
+// safe for concurrent use.
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
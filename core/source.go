@@ -2,6 +2,7 @@ package core
 
 import (
 	"math/rand"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,8 +18,36 @@ Keep in mind that Sources are not thread-safe in golang, and if you want to do
 large amounts of processing on several different Sources in parallel, you should
 generate random Sources for them using the "(*Definition).RandomSource()"
 function.
+
+Any RNG can be used as a source, such as NewPCG() for speed or CryptoRNG for
+rolls that need to be unpredictable.
 */
-var defaultSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+var defaultSource RNG = rand.New(rand.NewSource(newSeed()))
+
+/////////////
+// SEEDING //
+/////////////
+
+// Counter mixed into random seeds so two seeds made in the same nanosecond
+// still differ.
+var seedCounter atomic.Int64
+
+// Create a new random seed from the current time.
+func newSeed() int64 {
+	return deriveSeed(time.Now().UnixNano(), int(seedCounter.Add(1)))
+}
+
+/*
+Derive an independent seed from a master seed and an index using the
+SplitMix64 mixing function. Nearby masters and indices give unrelated seeds,
+so the streams of sources seeded this way don't overlap in practice.
+*/
+func deriveSeed(master int64, index int) int64 {
+	z := uint64(master) + uint64(index+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
 
 /////////////
 // GLOBALS //
@@ -31,8 +60,8 @@ func SetSeed(s int64) {
 	defaultSource = rand.New(rand.NewSource(s))
 }
 
-// Set the defaultSource based on a provide *rand.Rand Source.
-func SetSource(s *rand.Rand) {
+// Set the defaultSource to any RNG, such as NewPCG(seed) or CryptoRNG.
+func SetSource(s RNG) {
 	lock.Lock()
 	defer lock.Unlock()
 	defaultSource = s
@@ -42,7 +71,7 @@ func SetSource(s *rand.Rand) {
 func SetRandomSource() {
 	lock.Lock()
 	defer lock.Unlock()
-	defaultSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+	defaultSource = rand.New(rand.NewSource(newSeed()))
 }
 
 /////////////////
@@ -58,8 +87,8 @@ func (d *Definition) SetSeed(s int64) *Definition {
 	return d
 }
 
-// Set the defaultSource based on a provide *rand.Rand Source.
-func (d *Definition) SetSource(s *rand.Rand) *Definition {
+// Set the source to any RNG, such as NewPCG(seed) or CryptoRNG.
+func (d *Definition) SetSource(s RNG) *Definition {
 	d.source = s
 	for _, child := range d.Children {
 		child.SetSource(d.source)
@@ -69,7 +98,7 @@ func (d *Definition) SetSource(s *rand.Rand) *Definition {
 
 // Set the defaultSource randomly.
 func (d *Definition) SetRandomSource() *Definition {
-	d.source = rand.New(rand.NewSource(time.Now().UnixNano()))
+	d.source = rand.New(rand.NewSource(newSeed()))
 	for _, child := range d.Children {
 		child.SetSource(d.source)
 	}
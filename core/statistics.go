@@ -0,0 +1,144 @@
+package core
+
+import (
+	"math"
+	"sort"
+)
+
+// A single point of a cumulative distribution. Probability is the chance of a
+// roll being at most Value.
+type CDFPoint struct {
+	Value       int
+	Probability float64
+}
+
+/*
+Function for determining the smallest rolled value that at least the fraction
+"q" of rolls were at or below. Quantile(0.9) is the 90th percentile. Returns 0
+if there are no rolls.
+*/
+func (a *Analysis) Quantile(q float64) int {
+	values := a.values()
+	if len(values) == 0 {
+		return 0
+	}
+
+	target := quantileRank(q, a.N)
+	cumulative := 0
+	for _, value := range values {
+		cumulative += a.Rolls[value]
+		if cumulative >= target {
+			return value
+		}
+	}
+	return values[len(values)-1]
+}
+
+/*
+The number of rolls that need to be at or below the "q" quantile of "N" rolls.
+This is worked out once as an integer, with a little slack for rounding, so
+that Quantile(0.07) of 100 rolls is the 7th roll and not the 8th.
+*/
+func quantileRank(q float64, N int) int {
+	return int(math.Ceil(q*float64(N) - 1e-9))
+}
+
+// Function for determining the "p"th percentile, with "p" between 0 and 100.
+func (a *Analysis) Percentile(p float64) int {
+	return a.Quantile(p / 100)
+}
+
+// The median rolled value.
+func (a *Analysis) Median() int {
+	return a.Quantile(0.5)
+}
+
+// The most common rolled value. Ties go to the lowest value.
+func (a *Analysis) Mode() int {
+	mode := 0
+	modeCount := 0
+	for _, value := range a.values() {
+		if a.Rolls[value] > modeCount {
+			mode = value
+			modeCount = a.Rolls[value]
+		}
+	}
+	return mode
+}
+
+// The lowest rolled value, or 0 if there are no rolls.
+func (a *Analysis) Min() int {
+	values := a.values()
+	if len(values) == 0 {
+		return 0
+	}
+	return values[0]
+}
+
+// The highest rolled value, or 0 if there are no rolls.
+func (a *Analysis) Max() int {
+	values := a.values()
+	if len(values) == 0 {
+		return 0
+	}
+	return values[len(values)-1]
+}
+
+// The full cumulative distribution of the rolls, in ascending order of value.
+func (a *Analysis) CDF() []CDFPoint {
+	cdf := []CDFPoint{}
+	cumulative := 0
+	for _, value := range a.values() {
+		cumulative += a.Rolls[value]
+		cdf = append(cdf, CDFPoint{
+			Value:       value,
+			Probability: float64(cumulative) / float64(a.N),
+		})
+	}
+	return cdf
+}
+
+// The variance of the rolls. This is the square of "Deviation".
+func (a *Analysis) Variance() float64 {
+	return a.centralMoment(2)
+}
+
+/*
+The skewness of the rolls. Positive values mean a longer tail of high rolls,
+negative values a longer tail of low rolls, and 0 a symmetric distribution.
+*/
+func (a *Analysis) Skewness() float64 {
+	return a.centralMoment(3) / math.Pow(a.centralMoment(2), 1.5)
+}
+
+/*
+The excess kurtosis of the rolls. A normal distribution has an excess kurtosis
+of 0, flatter distributions like a single die are negative, and distributions
+with heavy tails, like exploding dice, are positive.
+*/
+func (a *Analysis) Kurtosis() float64 {
+	return a.centralMoment(4)/math.Pow(a.centralMoment(2), 2) - 3
+}
+
+/////////////
+// HELPERS //
+/////////////
+
+// The rolled values in ascending order
+func (a *Analysis) values() []int {
+	values := make([]int, 0, len(a.Rolls))
+	for value := range a.Rolls {
+		values = append(values, value)
+	}
+	sort.Ints(values)
+	return values
+}
+
+// The kth moment of the rolls about the mean
+func (a *Analysis) centralMoment(k float64) float64 {
+	moment := 0.0
+	for _, value := range a.values() {
+		moment += float64(a.Rolls[value]) * math.Pow(float64(value)-a.Mean, k)
+	}
+	return moment / float64(a.N)
+}
@@ -0,0 +1,43 @@
+package core
+
+import "testing"
+
+func TestQuantileUniform(t *testing.T) {
+	A := newAnalysis()
+	for value := 1; value <= 100; value++ {
+		A.Rolls[value] = 1
+	}
+	A.summarize()
+
+	for p := 1; p <= 100; p++ {
+		if got := A.Percentile(float64(p)); got != p {
+			t.Errorf("Percentile(%d) = %d, want %d", p, got, p)
+		}
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	A := newAnalysis()
+	A.Rolls[1] = 1
+	A.Rolls[2] = 2
+	A.Rolls[3] = 1
+	A.summarize()
+
+	tests := []struct {
+		q    float64
+		want int
+	}{
+		{0, 1},
+		{0.25, 1},
+		{0.26, 2},
+		{0.5, 2},
+		{0.75, 2},
+		{0.76, 3},
+		{1, 3},
+	}
+	for _, test := range tests {
+		if got := A.Quantile(test.q); got != test.want {
+			t.Errorf("Quantile(%v) = %d, want %d", test.q, got, test.want)
+		}
+	}
+}
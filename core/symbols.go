@@ -0,0 +1,123 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Encode a set of symbol counts as a string, such as "advantage=2,success=1".
+Symbols are sorted by name and zero counts are left out, so equal sets always
+encode the same way. This is the key used by "Analysis.Symbols".
+*/
+func EncodeSymbols(symbols map[string]int) string {
+	names := make([]string, 0, len(symbols))
+	for name, count := range symbols {
+		if count != 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + strconv.Itoa(symbols[name])
+	}
+	return strings.Join(parts, ",")
+}
+
+// Decode a string created by EncodeSymbols back into symbol counts.
+func DecodeSymbols(key string) (map[string]int, error) {
+	symbols := map[string]int{}
+	if key == "" {
+		return symbols, nil
+	}
+	for _, part := range strings.Split(key, ",") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("dice: invalid symbols %q", key)
+		}
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("dice: invalid symbols %q: %w", key, err)
+		}
+		symbols[name] = count
+	}
+	return symbols, nil
+}
+
+/*
+Every symbol that appeared in at least one roll, in alphabetical order. Each
+of these is an axis that can be passed to Marginal() or Joint().
+*/
+func (a *Analysis) Axes() []string {
+	seen := map[string]bool{}
+	for _, symbols := range a.decodedSymbols() {
+		for name := range symbols.counts {
+			seen[name] = true
+		}
+	}
+
+	axes := make([]string, 0, len(seen))
+	for name := range seen {
+		axes = append(axes, name)
+	}
+	sort.Strings(axes)
+	return axes
+}
+
+/*
+The number of rolls with each count of a single symbol, including the rolls
+where it didn't appear at all under 0.
+*/
+func (a *Analysis) Marginal(axis string) map[int]int {
+	marginal := map[int]int{}
+	counted := 0
+	for _, symbols := range a.decodedSymbols() {
+		marginal[symbols.counts[axis]] += symbols.rolls
+		counted += symbols.rolls
+	}
+	if a.N > counted {
+		marginal[0] += a.N - counted
+	}
+	return marginal
+}
+
+/*
+The number of rolls with each pair of counts of two symbols, such as
+Joint("success", "advantage")[[2]int{1, 2}] for the rolls with exactly one
+success and two advantages.
+*/
+func (a *Analysis) Joint(x, y string) map[[2]int]int {
+	joint := map[[2]int]int{}
+	counted := 0
+	for _, symbols := range a.decodedSymbols() {
+		joint[[2]int{symbols.counts[x], symbols.counts[y]}] += symbols.rolls
+		counted += symbols.rolls
+	}
+	if a.N > counted {
+		joint[[2]int{0, 0}] += a.N - counted
+	}
+	return joint
+}
+
+// The decoded symbol counts of the analysis and the number of rolls of each
+type symbolCounts struct {
+	counts map[string]int
+	rolls  int
+}
+
+// Decode every key of "Symbols". Keys that can't be decoded are skipped.
+func (a *Analysis) decodedSymbols() []symbolCounts {
+	decoded := make([]symbolCounts, 0, len(a.Symbols))
+	for key, rolls := range a.Symbols {
+		counts, err := DecodeSymbols(key)
+		if err != nil {
+			continue
+		}
+		decoded = append(decoded, symbolCounts{counts: counts, rolls: rolls})
+	}
+	return decoded
+}
@@ -26,6 +26,20 @@ func New(sides int) *core.Definition {
 	return d.SetDefaultSource()
 }
 
+/*
+Create a new *Definition which always rolls the same value. This is mostly
+useful for combining with other definitions, such as
+dice.New(8).Add(dice.Constant(3)).
+*/
+func Constant(value int) *core.Definition {
+	d := &core.Definition{
+		Children:   []*core.Definition{},
+		RollType:   core.ROLL_CONSTANT,
+		RollParams: map[string]interface{}{"value": value},
+	}
+	return d.SetDefaultSource()
+}
+
 /*
 Create a new *Definition with a specified weighting for each specified value.
 A weights map of:
@@ -50,3 +64,33 @@ func NewWeighted(weights map[int]int) *core.Definition {
 	}
 	return d.SetDefaultSource()
 }
+
+/*
+Create a new *Definition with explicitly listed faces, each with the same
+chance of being rolled. Each face has a value, which counts towards the total,
+and optional named symbols which are totalled in the Result's "Symbols". List a
+face more than once to make it more likely. An average die would be:
+
+	dice.NewFaces([]core.Face{
+		{Value: 2}, {Value: 3}, {Value: 3}, {Value: 4}, {Value: 4}, {Value: 5},
+	})
+*/
+func NewFaces(faces []core.Face) *core.Definition {
+	d := &core.Definition{
+		Children:   []*core.Definition{},
+		RollType:   core.ROLL_FACES,
+		RollParams: map[string]interface{}{"faces": faces},
+	}
+	return d.SetDefaultSource()
+}
+
+/*
+Create a new Fudge die, with two faces each of -1, 0 and +1.
+*/
+func NewFudge() *core.Definition {
+	return NewFaces([]core.Face{
+		{Value: -1}, {Value: -1},
+		{Value: 0}, {Value: 0},
+		{Value: 1}, {Value: 1},
+	})
+}
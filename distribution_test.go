@@ -0,0 +1,140 @@
+package dice
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flywingedai/dice/core"
+)
+
+// An exact distribution to check, by its mean and some of its probabilities
+type distributionTest struct {
+	name          string
+	d             *core.Definition
+	mean          float64
+	probabilities map[int]float64
+}
+
+func checkDistributions(t *testing.T, tests []distributionTest) {
+	t.Helper()
+	for _, test := range tests {
+		distribution, err := test.d.Distribution()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if math.Abs(distribution.Mean-test.mean) > 1e-9 {
+			t.Errorf("%s: mean = %v, want %v", test.name, distribution.Mean, test.mean)
+		}
+		for value, want := range test.probabilities {
+			if got := distribution.Probabilities[value]; math.Abs(got-want) > 1e-12 {
+				t.Errorf("%s: P(%d) = %v, want %v", test.name, value, got, want)
+			}
+		}
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	checkDistributions(t, []distributionTest{
+		{"1d6", New(6), 3.5, map[int]float64{1: 1.0 / 6, 6: 1.0 / 6}},
+		{"3d6", New(6).Multiple(3), 10.5, map[int]float64{3: 1.0 / 216, 10: 27.0 / 216}},
+		{"2d6", New(6).Multiple(2), 7, map[int]float64{7: 1.0 / 6, 12: 1.0 / 36}},
+		{"advantage", New(20).Advantage(), 13.825, map[int]float64{20: 39.0 / 400, 1: 1.0 / 400}},
+		{"disadvantage", New(20).Disadvantage(), 7.175, map[int]float64{1: 39.0 / 400}},
+		{"weighted", NewWeighted(map[int]int{1: 1, 4: 3}), 3.25, map[int]float64{1: 0.25, 4: 0.75}},
+	})
+}
+
+func TestDistributionTooManyOutcomes(t *testing.T) {
+	if _, err := New(1 << 26).Distribution(); err == nil {
+		t.Errorf("sides: expected an error")
+	}
+
+	defer func(limit int) { core.MaxOutcomes = limit }(core.MaxOutcomes)
+	core.MaxOutcomes = 2
+	if _, err := NewWeighted(map[int]int{1: 1, 2: 1, 3: 1}).Distribution(); err == nil {
+		t.Errorf("weighted: expected an error")
+	}
+}
+
+func TestDistributionReroll(t *testing.T) {
+	checkDistributions(t, []distributionTest{
+
+		// Rerolling a 1 once keeps the reroll 1/6 of the time
+		{"1d6ro1", New(6).Reroll(core.Equal(1), true), 1.0/6*3.5 + 20.0/6, map[int]float64{1: 1.0 / 36, 2: 7.0 / 36}},
+
+		// Rerolling until it isn't a 1 is a d6 without its 1, up to the
+		// vanishing chance of hitting the reroll limit
+		{"1d6r1", New(6).Reroll(core.Equal(1), false), 4, map[int]float64{2: 0.2}},
+
+		// Great Weapon Fighting on 2d6
+		{"2d6ro<=2", New(6).Reroll(core.AtMost(2), true).Multiple(2), 2 * (2.0/6*3.5 + 18.0/6), nil},
+	})
+}
+
+func TestDistributionKeep(t *testing.T) {
+	checkDistributions(t, []distributionTest{
+		{"4d6kh3", New(6).Multiple(4).KeepHighest(3), 15869.0 / 1296, map[int]float64{3: 1.0 / 1296, 18: 21.0 / 1296}},
+		{"4d6dl1", New(6).Multiple(4).DropLowest(1), 15869.0 / 1296, nil},
+		{"2d20kl1", New(20).Multiple(2).KeepLowest(1), 7.175, map[int]float64{1: 39.0 / 400}},
+		{"3d6dh2", New(6).Multiple(3).DropHighest(2), 441.0 / 216, map[int]float64{6: 1.0 / 216}},
+	})
+}
+
+func TestDistributionCount(t *testing.T) {
+	checkDistributions(t, []distributionTest{
+
+		// Each die is worth -1 on a 1, 2 on a 10 and 1 on an 8 or 9
+		{"5d10 successes", New(10).Multiple(5).CountSuccesses(core.AtLeast(8), []int{1}, []int{10}), 1.5, nil},
+		{"5d10 at least 8", New(10).Multiple(5).CountAtLeast(8), 1.5, map[int]float64{0: math.Pow(0.7, 5), 5: math.Pow(0.3, 5)}},
+	})
+}
+
+func TestDistributionArithmetic(t *testing.T) {
+	checkDistributions(t, []distributionTest{
+		{"1d8+3", New(8).Plus(3), 7.5, map[int]float64{4: 1.0 / 8, 11: 1.0 / 8}},
+		{"1d6-1d4", New(6).Sub(New(4)), 1, map[int]float64{-3: 1.0 / 24, 5: 1.0 / 24}},
+		{"2*1d6", New(6).Mul(2), 7, map[int]float64{7: 0, 12: 1.0 / 6}},
+		{"-1d4", New(4).Neg(), -2.5, map[int]float64{-4: 0.25}},
+		{"3d6/2", New(6).Multiple(3).Div(2, core.ROUND_DOWN), 5, map[int]float64{1: 1.0 / 216}},
+		{"1d6*1d6", New(6).Add(New(6)).Product(), 12.25, map[int]float64{36: 1.0 / 36, 6: 4.0 / 36}},
+	})
+}
+
+func TestDistributionConditional(t *testing.T) {
+	hit := []core.Branch{core.BranchValues(20), core.BranchAtLeast(11)}
+	checkDistributions(t, []distributionTest{
+		{"hit or miss", New(20).Conditional(hit[1:], Constant(10), Constant(0)), 5, map[int]float64{0: 0.5}},
+		{"critical", New(20).Conditional(hit, Constant(20), Constant(10)), 5.5, map[int]float64{20: 0.05, 10: 0.45, 0: 0.5}},
+		{"no fallback", New(20).Conditional(hit[:1], New(6).Multiple(2)), 0.35, map[int]float64{0: 0.95}},
+	})
+}
+
+func TestDistributionExplode(t *testing.T) {
+	checkDistributions(t, []distributionTest{
+
+		// Every roll explodes with p = 1/6, so a mean of 3.5 / (1 - 1/6), and
+		// a 6 always explodes so the total is never exactly 6
+		{"1d6!!", New(6).Explode(6, 100), 4.2, map[int]float64{6: 0, 7: 1.0 / 36, 13: 1.0 / 216}},
+		{"1d6!", New(6).ExplodePool(1, 6, 100), 4.2, map[int]float64{6: 0, 7: 1.0 / 36}},
+		{"3d6!", New(6).ExplodePool(3, 6, 100), 12.6, map[int]float64{3: 1.0 / 216}},
+		{"4d10!", New(10).ExplodePool(4, 10, 100), 4 * 5.5 / 0.9, map[int]float64{4: 1e-4}},
+
+		// With at most 1 extra roll, a 6 is followed by one more roll
+		{"1d6 explode once", New(6).Explode(6, 1), 3.5 + 3.5/6, map[int]float64{12: 1.0 / 36}},
+		{"2d6! depth 10", New(6).ExplodePool(2, 6, 10), 8.4 * (1 - math.Pow(1.0/6, 11)), nil},
+
+		// Penetrating extra rolls are worth 2.5 on average
+		{"1d6!!p", New(6).ExplodePenetrating(6, 100), 3.5 + 2.5/5, map[int]float64{6: 1.0 / 36}},
+		{"1d6!p", New(6).ExplodePoolPenetrating(1, 6, 100), 3.5 + 2.5/5, map[int]float64{6: 1.0 / 36}},
+
+		// The extra dice are kept on their own, so the highest is never more
+		// than 6, unlike compounding
+		{"2d6!kh1", New(6).ExplodePool(2, 6, 3).KeepHighest(1), 161.0 / 36, map[int]float64{6: 11.0 / 36}},
+		{"2d6!!kh1", New(6).Explode(6, 3).Multiple(2).KeepHighest(1), 9730889.0 / 1679616, map[int]float64{6: 0}},
+
+		// 10-again, where each die succeeds on 8+ and explodes on a 10
+		{"10-again", New(10).ExplodePool(1, 10, 100).CountAtLeast(8), 0.3 / 0.9, nil},
+		{"5d10 10-again", New(10).ExplodePool(5, 10, 100).CountAtLeast(8), 5 * 0.3 / 0.9, nil},
+	})
+}
@@ -1,3 +1,3 @@
 module github.com/flywingedai/dice
 
-go 1.21.5
+go 1.22
@@ -0,0 +1,23 @@
+package dice
+
+import (
+	"encoding/json"
+
+	"github.com/flywingedai/dice/core"
+)
+
+/*
+Load a *Definition from JSON, such as one previously produced by
+json.Marshal(). The whole tree is validated before it is returned, and the
+default source is attached, so the *Definition is ready to roll.
+*/
+func LoadJSON(data []byte) (*core.Definition, error) {
+	d := &core.Definition{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, err
+	}
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
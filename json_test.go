@@ -0,0 +1,69 @@
+package dice
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/flywingedai/dice/core"
+)
+
+func TestLoadJSONRoundTrip(t *testing.T) {
+	attack := New(20).CriticalOn(19)
+	tests := map[string]*core.Definition{
+		"sides":       New(6),
+		"multiple":    New(6).Multiple(3),
+		"advantage":   New(20).Advantage(),
+		"weighted":    NewWeighted(map[int]int{1: 2, 3: 1, 10: 4}),
+		"constant":    New(8).Plus(3),
+		"explode":     New(6).ExplodePenetrating(6, 10),
+		"explodePool": New(10).ExplodePool(5, 10, 100).CountAtLeast(8),
+		"reroll":      New(6).Reroll(core.AtMost(2), true),
+		"keep":        New(6).Multiple(4).KeepHighest(3),
+		"count":       New(10).Multiple(5).CountSuccesses(core.AtLeast(8), []int{1}, []int{10}),
+		"arithmetic":  New(6).Multiple(2).Div(2, core.ROUND_UP).Mul(3),
+		"opposed":     New(20).Contest(New(20), core.TIES_DEFENDER),
+		"conditional": attack.Conditional([]core.Branch{core.BranchAtLeast(15), core.BranchValues(1, 2)}, New(8), Constant(0), New(4)),
+		"critical":    attack.FumbleOn(2),
+		"faces": NewFaces([]core.Face{
+			{Value: 1, Symbols: map[string]int{"success": 1}},
+			{Value: 0},
+		}).Multiple(2).Cancel(map[string]string{"success": "failure"}, "success"),
+	}
+
+	for name, d := range tests {
+		data, err := json.Marshal(d)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		decoded, err := LoadJSON(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		// Compare the exported trees, as loading fills in unexported state
+		want, got := d.Copy(), decoded.Copy()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: decoded definition differs\n got: %#v\nwant: %#v", name, got, want)
+		}
+	}
+}
+
+func TestLoadJSONErrors(t *testing.T) {
+	tests := map[string]string{
+		"null child":    `{"rollType":"skip","aggregationType":"sum","children":[null]}`,
+		"unknown roll":  `{"rollType":"sidez","rollParams":{"sides":6}}`,
+		"bad params":    `{"rollType":"sides","rollParams":{"sides":"six"}}`,
+		"bad index":     `{"rollType":"multiple","rollParams":{"count":2},"aggregationType":"sumIndex","aggregationParams":{"indices":[5]},"children":[{"rollType":"sides","rollParams":{"sides":6}}]}`,
+		"missing child": `{"rollType":"multiple","rollParams":{"count":2},"aggregationType":"sum"}`,
+		"lower case":    `{"rolltype":"sides","rollParams":{"sides":6}}`,
+		"unknown field": `{"rollType":"sides","rollParams":{"sides":6},"agregationParams":{}}`,
+		"nested field":  `{"rollType":"multiple","rollParams":{"count":2},"aggregationType":"sum","children":[{"rollType":"sides","rollParams":{"sides":6},"sides":6}]}`,
+		"bad symbol":    `{"rollType":"faces","rollParams":{"faces":[{"value":1,"symbols":{"a=1,b":1}}]}}`,
+	}
+	for name, data := range tests {
+		if _, err := LoadJSON([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,539 @@
+package dice
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flywingedai/dice/core"
+)
+
+/*
+Parse builds a *Definition from standard dice notation. The notation is case
+insensitive and ignores whitespace. Supported forms are:
+
+	NdS        roll N dice with S sides (N defaults to 1, "d%" is a d100)
+	NdF        roll N Fudge dice
+	NdSkhK     keep the highest K dice ("k" is shorthand for "kh")
+	NdSklK     keep the lowest K dice
+	NdSdhK     drop the highest K dice
+	NdSdlK     drop the lowest K dice
+	NdS!       exploding dice, adding another die on the highest face
+	NdS!>=T    exploding dice, adding another die on T or higher ("!>T" also works)
+	NdS!!      compounding exploding dice, adding the extra rolls onto the die
+	NdS!p      penetrating exploding dice ("!!p" compounds them)
+	NdSrC      reroll dice until they don't match C
+	NdSroC     reroll dice matching C once
+	+ -        add or subtract terms and constant modifiers
+	*          multiply terms together
+	/          divide by a constant, rounding down
+	( )        grouping
+
+K defaults to 1 when omitted, so "2d20kh" is the same as "2d20kh1". Exploding
+dice explode at most ParseExplodeDepth times. The extra dice from "!" join the
+pool, so "5d10!kh3" can keep an extra die and "5d10!kh6" can keep more dice
+than were first rolled, while "!!" adds them onto the die that exploded. C is
+a number, optionally preceded by one of "=", "<", "<=", ">" or ">=", so "r<3"
+rerolls 1s and 2s and "r1" rerolls only 1s. Dividing by a term that contains
+dice is recognised but not supported, and returns an error.
+
+The returned *Definition is built from the same trees that "dice.New()" and
+the chain methods produce, and has the default source attached.
+*/
+func Parse(notation string) (*core.Definition, error) {
+	p := &parser{input: strings.ToLower(notation)}
+
+	o, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	if p.position < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.position])
+	}
+
+	return o.definition().SetDefaultSource(), nil
+}
+
+// Error returned by Parse for notation that can be read but not yet built.
+var ErrUnsupported = errors.New("unsupported notation")
+
+// The maximum number of extra rolls for exploding dice created by Parse.
+var ParseExplodeDepth = 100
+
+// The deepest Parse allows parentheses and signs to be nested, so notation like
+// "((((...))))" fails rather than recursing without bound.
+var ParseMaxNesting = 100
+
+////////////
+// PARSER //
+////////////
+
+type parser struct {
+	input    string
+	position int
+
+	// How deeply the current unary() call is nested
+	depth int
+}
+
+/*
+Intermediate value for the parser. Constants are kept as plain integers so
+that modifiers like "+2" or "-(1+1)" can be folded before being turned into a
+*Definition.
+*/
+type operand struct {
+	constant bool
+	value    int
+
+	// Set if this operand is a parser generated sum, so further terms can be
+	// appended to it rather than nesting another node
+	sum bool
+
+	d *core.Definition
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("dice: parse %q at position %d: %s", p.input, p.position, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) unsupported(feature string) error {
+	return fmt.Errorf("dice: parse %q at position %d: %s: %w", p.input, p.position, feature, ErrUnsupported)
+}
+
+func (p *parser) skipSpaces() {
+	for p.position < len(p.input) && strings.ContainsRune(" \t\r\n", rune(p.input[p.position])) {
+		p.position++
+	}
+}
+
+// Returns the next non-space character, or 0 at the end of the input
+func (p *parser) peek() byte {
+	p.skipSpaces()
+	if p.position >= len(p.input) {
+		return 0
+	}
+	return p.input[p.position]
+}
+
+// Consumes the token if it is next in the input
+func (p *parser) consume(token string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.input[p.position:], token) {
+		p.position += len(token)
+		return true
+	}
+	return false
+}
+
+// Reads an unsigned integer. The bool is false if no digits were found.
+func (p *parser) number() (int, bool, error) {
+	p.skipSpaces()
+	start := p.position
+	value := 0
+	for p.position < len(p.input) && p.input[p.position] >= '0' && p.input[p.position] <= '9' {
+		value = value*10 + int(p.input[p.position]-'0')
+		if value > 1<<30 {
+			p.position = start
+			return 0, false, p.errorf("number too large")
+		}
+		p.position++
+	}
+	return value, p.position > start, nil
+}
+
+/////////////////
+// EXPRESSIONS //
+/////////////////
+
+// expression := term (("+" | "-") term)*
+func (p *parser) expression() (*operand, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.consume("+"):
+			right, err := p.term()
+			if err != nil {
+				return nil, err
+			}
+			left = add(left, right)
+
+		case p.consume("-"):
+			right, err := p.term()
+			if err != nil {
+				return nil, err
+			}
+			if right.constant {
+				left = add(left, &operand{constant: true, value: -right.value})
+			} else {
+				left = &operand{d: left.definition().Sub(right.definition())}
+			}
+
+		default:
+			return left, nil
+		}
+	}
+}
+
+// term := unary (("*" | "/") unary)*
+func (p *parser) term() (*operand, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.consume("*"):
+			right, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case left.constant && right.constant:
+				left = &operand{constant: true, value: left.value * right.value}
+			case left.constant:
+				left = &operand{d: right.d.Mul(left.value)}
+			case right.constant:
+				left = &operand{d: left.d.Mul(right.value)}
+			default:
+				left = &operand{d: left.d.Add(right.d).Product()}
+			}
+
+		case p.consume("/"):
+			right, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			if !right.constant {
+				return nil, p.unsupported("dividing by dice")
+			}
+			if right.value == 0 {
+				return nil, p.errorf("division by zero")
+			}
+			if left.constant {
+				left = &operand{constant: true, value: floorDiv(left.value, right.value)}
+			} else {
+				left = &operand{d: left.d.Div(right.value, core.ROUND_DOWN)}
+			}
+
+		default:
+			return left, nil
+		}
+	}
+}
+
+// unary := ("-" | "+") unary | primary
+func (p *parser) unary() (*operand, error) {
+
+	// Every level of signs or parentheses passes through here
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > ParseMaxNesting {
+		return nil, p.errorf("nested more than %d deep", ParseMaxNesting)
+	}
+
+	switch {
+	case p.consume("-"):
+		o, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if !o.constant {
+			return &operand{d: o.d.Neg()}, nil
+		}
+		return &operand{constant: true, value: -o.value}, nil
+
+	case p.consume("+"):
+		return p.unary()
+	}
+	return p.primary()
+}
+
+// primary := "(" expression ")" | dice | number
+func (p *parser) primary() (*operand, error) {
+	if p.consume("(") {
+		o, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, p.errorf("expected ')'")
+		}
+		return o, nil
+	}
+
+	count, ok, err := p.number()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() == 'd' {
+		if !ok {
+			count = 1
+		}
+		return p.dice(count)
+	}
+	if !ok {
+		if p.peek() == 0 {
+			return nil, p.errorf("unexpected end of notation")
+		}
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+	return &operand{constant: true, value: count}, nil
+}
+
+//////////
+// DICE //
+//////////
+
+// dice := "d" (sides | "%" | "f") modifier*
+func (p *parser) dice(count int) (*operand, error) {
+	p.consume("d")
+	if count <= 0 {
+		return nil, p.errorf("dice count must be positive")
+	}
+
+	// The lowest and highest faces of the die
+	lowest, highest := 1, 100
+	die := New(100)
+	switch {
+	case p.consume("%"):
+	case p.consume("f"):
+		lowest, highest = -1, 1
+		die = NewFudge()
+	default:
+		n, ok, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		if !ok || n <= 0 {
+			return nil, p.errorf("expected a positive number of sides")
+		}
+		highest = n
+		die = New(n)
+	}
+
+	// Read any modifiers. At most one keep or drop, one explode and one
+	// reroll is allowed per group.
+	var keep func(*core.Definition) *core.Definition
+	kept := 0
+	var explode func(*core.Definition) *core.Definition
+	exploded := false
+	rerolled := false
+	for {
+		switch {
+		case p.consume("kl"):
+			if err := p.keep(&keep, &kept, (*core.Definition).KeepLowest); err != nil {
+				return nil, err
+			}
+
+		case p.consume("kh"), p.consume("k"):
+			if err := p.keep(&keep, &kept, (*core.Definition).KeepHighest); err != nil {
+				return nil, err
+			}
+
+		case p.consume("dh"):
+			if err := p.keep(&keep, &kept, (*core.Definition).DropHighest); err != nil {
+				return nil, err
+			}
+
+		case p.consume("dl"):
+			if err := p.keep(&keep, &kept, (*core.Definition).DropLowest); err != nil {
+				return nil, err
+			}
+
+		case p.consume("!"):
+			if exploded {
+				return nil, p.errorf("only one explode modifier is allowed")
+			}
+			exploded = true
+
+			compound := p.consume("!")
+			penetrate := p.consume("p")
+
+			threshold, err := p.threshold(lowest, highest)
+			if err != nil {
+				return nil, err
+			}
+
+			// Compounding explodes each die in place, otherwise the pool
+			// explodes once it is rolled
+			switch {
+			case compound && penetrate:
+				die = die.ExplodePenetrating(threshold, ParseExplodeDepth)
+			case compound:
+				die = die.Explode(threshold, ParseExplodeDepth)
+			case penetrate:
+				explode = func(d *core.Definition) *core.Definition {
+					return d.ExplodePoolPenetrating(count, threshold, ParseExplodeDepth)
+				}
+			default:
+				explode = func(d *core.Definition) *core.Definition {
+					return d.ExplodePool(count, threshold, ParseExplodeDepth)
+				}
+			}
+
+		case p.consume("r"):
+			if rerolled {
+				return nil, p.errorf("only one reroll modifier is allowed")
+			}
+			rerolled = true
+
+			once := p.consume("o")
+			condition, err := p.condition()
+			if err != nil {
+				return nil, err
+			}
+			die = die.Reroll(condition, once)
+
+		default:
+
+			// Exploding pools can have more dice than they started with, so
+			// they can keep or drop more than "count"
+			if kept > count && explode == nil {
+				return nil, p.errorf("cannot keep or drop %d of %d dice without exploding them with \"!\"", kept, count)
+			}
+
+			if count == 1 && keep == nil && explode == nil {
+				return &operand{d: die}, nil
+			}
+
+			d := die.Multiple(count)
+			if explode != nil {
+				d = explode(die)
+			}
+			if keep != nil {
+				d = keep(d)
+			}
+			return &operand{d: d}, nil
+		}
+	}
+}
+
+// Reads the optional count after a keep or drop modifier, and sets the chain
+// method to apply to the pool and the number of dice it keeps or drops
+func (p *parser) keep(keep *func(*core.Definition) *core.Definition, kept *int, method func(*core.Definition, int) *core.Definition) error {
+	if *keep != nil {
+		return p.errorf("only one keep or drop modifier is allowed")
+	}
+
+	n, ok, err := p.number()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		n = 1
+	}
+
+	*kept = n
+	*keep = func(d *core.Definition) *core.Definition { return method(d, n) }
+	return nil
+}
+
+// Reads the optional ">=T" or ">T" after an explode, defaulting to the highest
+// face of the die. A threshold that even the lowest face reaches would explode
+// on every roll, so it is rejected.
+func (p *parser) threshold(lowest, highest int) (int, error) {
+	threshold := highest
+	switch {
+	case p.consume(">="):
+		n, err := p.thresholdNumber()
+		if err != nil {
+			return 0, err
+		}
+		threshold = n
+	case p.consume(">"):
+		n, err := p.thresholdNumber()
+		if err != nil {
+			return 0, err
+		}
+		threshold = n + 1
+	}
+
+	if threshold <= lowest {
+		return 0, p.errorf("exploding on every roll is not allowed")
+	}
+	return threshold, nil
+}
+
+// Reads the number after ">=" or ">" in an explode threshold
+func (p *parser) thresholdNumber() (int, error) {
+	n, ok, err := p.number()
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, p.errorf("expected a number")
+	}
+	return n, nil
+}
+
+// Reads a comparison like "<3" or "5", where no operator means "="
+func (p *parser) condition() (core.Condition, error) {
+	operator := core.COMPARE_EQUAL
+	for _, o := range []string{core.COMPARE_LESS_EQUAL, core.COMPARE_GREATER_EQUAL, core.COMPARE_LESS, core.COMPARE_GREATER, core.COMPARE_EQUAL} {
+		if p.consume(o) {
+			operator = o
+			break
+		}
+	}
+
+	n, ok, err := p.number()
+	if err != nil {
+		return core.Condition{}, err
+	}
+	if !ok {
+		return core.Condition{}, p.errorf("expected a number")
+	}
+	return core.Condition{Operator: operator, Value: n}, nil
+}
+
+/////////////
+// HELPERS //
+/////////////
+
+// Returns the *Definition for the operand, turning constants into a constant
+// roll
+func (o *operand) definition() *core.Definition {
+	if o.constant {
+		return Constant(o.value)
+	}
+	return o.d
+}
+
+// Integer division rounding down, to match ROUND_DOWN
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// Adds two operands together, folding constants and flattening sums
+func add(left, right *operand) *operand {
+	if left.constant && right.constant {
+		return &operand{constant: true, value: left.value + right.value}
+	}
+
+	if left.sum {
+		left.d.Children = append(left.d.Children, right.definition())
+		return left
+	}
+
+	return &operand{
+		sum: true,
+		d: &core.Definition{
+			Children:          []*core.Definition{left.definition(), right.definition()},
+			RollType:          core.ROLL_SKIP,
+			RollParams:        map[string]interface{}{},
+			AggregationType:   core.AGGREGATE_SUM,
+			AggregationParams: map[string]interface{}{},
+		},
+	}
+}
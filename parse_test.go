@@ -0,0 +1,134 @@
+package dice
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flywingedai/dice/core"
+)
+
+// The flattened sum the parser builds for "a + b + ..."
+func sum(children ...*core.Definition) *core.Definition {
+	return &core.Definition{
+		Children:          children,
+		RollType:          core.ROLL_SKIP,
+		RollParams:        map[string]interface{}{},
+		AggregationType:   core.AGGREGATE_SUM,
+		AggregationParams: map[string]interface{}{},
+	}
+}
+
+func TestParse(t *testing.T) {
+	depth := ParseExplodeDepth
+	tests := []struct {
+		notation string
+		want     *core.Definition
+	}{
+		{"d6", New(6)},
+		{"1d6", New(6)},
+		{"3d6", New(6).Multiple(3)},
+		{"d%", New(100)},
+		{"4dF", NewFudge().Multiple(4)},
+		{" 2 D 20 ", New(20).Multiple(2)},
+		{"7", Constant(7)},
+		{"3+4*2", Constant(11)},
+
+		// Keep and drop
+		{"4d6kh3", New(6).Multiple(4).KeepHighest(3)},
+		{"4d6k3", New(6).Multiple(4).KeepHighest(3)},
+		{"2d20kh", New(20).Multiple(2).KeepHighest(1)},
+		{"2d20kl1", New(20).Multiple(2).KeepLowest(1)},
+		{"4d6dl", New(6).Multiple(4).DropLowest(1)},
+		{"5d6dh2", New(6).Multiple(5).DropHighest(2)},
+		{"1d20k1", New(20).Multiple(1).KeepHighest(1)},
+
+		// Exploding
+		{"1d6!", New(6).ExplodePool(1, 6, depth)},
+		{"3d6!", New(6).ExplodePool(3, 6, depth)},
+		{"5d10!>=8", New(10).ExplodePool(5, 8, depth)},
+		{"5d10!>8", New(10).ExplodePool(5, 9, depth)},
+		{"2d6!p", New(6).ExplodePoolPenetrating(2, 6, depth)},
+		{"2d6!!", New(6).Explode(6, depth).Multiple(2)},
+		{"1d6!!", New(6).Explode(6, depth)},
+		{"2d6!!p>=5", New(6).ExplodePenetrating(5, depth).Multiple(2)},
+		{"5d10!kh3", New(10).ExplodePool(5, 10, depth).KeepHighest(3)},
+		{"5d10!kh6", New(10).ExplodePool(5, 10, depth).KeepHighest(6)},
+		{"5d10kh6!", New(10).ExplodePool(5, 10, depth).KeepHighest(6)},
+		{"4df!", NewFudge().ExplodePool(4, 1, depth)},
+		{"4df!>=0", NewFudge().ExplodePool(4, 0, depth)},
+
+		// Rerolls
+		{"1d6r1", New(6).Reroll(core.Equal(1), false)},
+		{"2d6ro<3", New(6).Reroll(core.LessThan(3), true).Multiple(2)},
+		{"4d6r<=2kh3", New(6).Reroll(core.AtMost(2), false).Multiple(4).KeepHighest(3)},
+		{"3d6!r1", New(6).Reroll(core.Equal(1), false).ExplodePool(3, 6, depth)},
+
+		// Arithmetic
+		{"1d8+3", sum(New(8), Constant(3))},
+		{"1d8-2", sum(New(8), Constant(-2))},
+		{"2d6+1d4+3", sum(New(6).Multiple(2), New(4), Constant(3))},
+		{"1d6-1d4", New(6).Sub(New(4))},
+		{"-1d4", New(4).Neg()},
+		{"2*1d6", New(6).Mul(2)},
+		{"1d6*3", New(6).Mul(3)},
+		{"1d6*1d4", New(6).Add(New(4)).Product()},
+		{"3d6/2", New(6).Multiple(3).Div(2, core.ROUND_DOWN)},
+		{"(1d6+1)*2", sum(New(6), Constant(1)).Mul(2)},
+		{"-7/2", Constant(-4)},
+		{strings.Repeat("(", 99) + "1d6" + strings.Repeat(")", 99), New(6)},
+	}
+
+	for _, test := range tests {
+		d, err := Parse(test.notation)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.notation, err)
+			continue
+		}
+
+		// Compare the exported trees, as the source isn't part of the notation
+		want, got := test.want.Copy(), d.Copy()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse(%q) differs\n got: %#v\nwant: %#v", test.notation, got, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"d",
+		"2d",
+		"d0",
+		"0d6",
+		"1d6+",
+		"(1d6",
+		"1d6)",
+		"1d6x",
+		"4d6kh5",
+		"4d6!!kh5",
+		"4d6khkl",
+		"1d6!!!",
+		"1d6!>=1",
+		"1d6!>0",
+		"1d1!",
+		"3d1!!",
+		"1d6!>=",
+		"1d6r",
+		"1d6rr1",
+		"1d6/0",
+		"99999999999d6",
+		strings.Repeat("(", 101) + "1d6" + strings.Repeat(")", 101),
+		strings.Repeat("-", 500_000) + "1",
+	}
+	for _, notation := range tests {
+		if d, err := Parse(notation); err == nil {
+			t.Errorf("Parse(%q) = %#v, expected an error", notation, d.Copy())
+		}
+	}
+
+	if _, err := Parse("1d6/1d4"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Parse(%q) = %v, want ErrUnsupported", "1d6/1d4", err)
+	}
+}
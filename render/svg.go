@@ -0,0 +1,191 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/flywingedai/dice/core"
+)
+
+// A single analysis to draw on an SVG chart.
+type Series struct {
+	Label    string
+	Analysis *core.Analysis
+}
+
+// Options for SVG.
+type SVGOptions struct {
+
+	// The size of the image in pixels. Defaults to 640 by 400, and smaller
+	// images are enlarged to leave room for the plot inside the margins.
+	Width  int
+	Height int
+
+	// Optional title drawn above the chart.
+	Title string
+
+	// Draw the percent of rolls of at least each value, instead of the
+	// percent of rolls of each value.
+	AtLeast bool
+}
+
+// Colors for each series, repeating after the last
+var palette = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7"}
+
+// Space around the plot for the axes, labels and legend
+const (
+	marginTop    = 40
+	marginRight  = 20
+	marginBottom = 40
+	marginLeft   = 56
+
+	// The smallest plot drawn inside the margins
+	minPlotSize = 40
+)
+
+/*
+Write a standalone SVG line chart of one or more analyses overlaid on the same
+axes, such as the same attack with and without advantage. Each series is drawn
+in its own color with a legend, the x axis covers every value rolled by any of
+the series and the y axis is the percent of rolls.
+*/
+func SVG(w io.Writer, series []Series, opts SVGOptions) error {
+	width, height := opts.Width, opts.Height
+	if width <= 0 {
+		width = 640
+	}
+	if height <= 0 {
+		height = 400
+	}
+	width = max(width, marginLeft+marginRight+minPlotSize)
+	height = max(height, marginTop+marginBottom+minPlotSize)
+	plotWidth := float64(width - marginLeft - marginRight)
+	plotHeight := float64(height - marginTop - marginBottom)
+
+	// Find the range of both axes over every series
+	points := make([]map[int]float64, len(series))
+	low, high := math.MaxInt, math.MinInt
+	top := 0.0
+	for i, s := range series {
+		points[i] = seriesPoints(s.Analysis, opts.AtLeast)
+		for value, y := range points[i] {
+			low, high = min(low, value), max(high, value)
+			top = math.Max(top, y)
+		}
+	}
+	if low > high {
+		low, high = 0, 1
+	}
+	if low == high {
+		low, high = low-1, high+1
+	}
+	step := tickStep(top)
+	top = math.Max(step, math.Ceil(top/step)*step)
+
+	x := func(value int) float64 {
+		return marginLeft + float64(value-low)/float64(high-low)*plotWidth
+	}
+	y := func(p float64) float64 {
+		return marginTop + plotHeight - p/top*plotHeight
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(b, `<rect width="%d" height="%d" fill="white"/>`+"\n", width, height)
+	if opts.Title != "" {
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" font-size="16">%s</text>`+"\n", width/2, marginTop/2+4, html.EscapeString(opts.Title))
+	}
+
+	// Horizontal grid lines with percent labels
+	for p := 0.0; p <= top+step/2; p += step {
+		fmt.Fprintf(b, `<line x1="%d" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#ddd"/>`+"\n", marginLeft, y(p), marginLeft+plotWidth, y(p))
+		fmt.Fprintf(b, `<text x="%d" y="%.1f" text-anchor="end">%s%%</text>`+"\n", marginLeft-6, y(p)+4, formatPercent(p))
+	}
+
+	// Value labels along the x axis, thinned out so they don't overlap. The
+	// loop stops before stepping past "high" so "value" can't overflow.
+	every := max(1, int(math.Ceil((float64(high)-float64(low)+1)*28/plotWidth)))
+	for value := low; ; value += every {
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#333"/>`+"\n", x(value), y(0), x(value), y(0)+4)
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="middle">%d</text>`+"\n", x(value), y(0)+18, value)
+		if high-value < every {
+			break
+		}
+	}
+	fmt.Fprintf(b, `<line x1="%d" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#333"/>`+"\n", marginLeft, y(0), marginLeft+plotWidth, y(0))
+
+	// One line per series, with a dot on every value
+	for i, s := range series {
+		color := palette[i%len(palette)]
+		values := sortedValues(points[i])
+
+		coordinates := make([]string, len(values))
+		for j, value := range values {
+			coordinates[j] = fmt.Sprintf("%.1f,%.1f", x(value), y(points[i][value]))
+		}
+		fmt.Fprintf(b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", strings.Join(coordinates, " "), color)
+		for _, value := range values {
+			fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s %d: %.2f%%</title></circle>`+"\n",
+				x(value), y(points[i][value]), color, html.EscapeString(s.Label), value, points[i][value])
+		}
+
+		// Legend in the top right of the plot
+		legendY := marginTop + 8 + 18*i
+		fmt.Fprintf(b, `<rect x="%.1f" y="%d" width="12" height="12" fill="%s"/>`+"\n", marginLeft+plotWidth-140, legendY, color)
+		fmt.Fprintf(b, `<text x="%.1f" y="%d">%s</text>`+"\n", marginLeft+plotWidth-122, legendY+10, html.EscapeString(s.Label))
+	}
+
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// The percent of rolls of, or of at least, each rolled value
+func seriesPoints(A *core.Analysis, atLeast bool) map[int]float64 {
+	points := map[int]float64{}
+	remaining := A.N
+	for _, point := range A.CDF() {
+		count := A.Rolls[point.Value]
+		if atLeast {
+			points[point.Value] = percent(remaining, A.N)
+		} else {
+			points[point.Value] = percent(count, A.N)
+		}
+		remaining -= count
+	}
+	return points
+}
+
+// A round step for the percent axis giving about 5 grid lines up to "top"
+func tickStep(top float64) float64 {
+	if top <= 0 {
+		return 1
+	}
+	raw := top / 5
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, multiple := range []float64{1, 2, 5, 10} {
+		if raw <= multiple*magnitude {
+			return multiple * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// Format a percent without trailing zeros, such as "2.5" or "10"
+func formatPercent(p float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", p), "0"), ".")
+}
+
+// The keys of a map in ascending order
+func sortedValues(m map[int]float64) []int {
+	values := make([]int, 0, len(m))
+	for value := range m {
+		values = append(values, value)
+	}
+	sort.Ints(values)
+	return values
+}
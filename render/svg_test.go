@@ -0,0 +1,49 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/flywingedai/dice"
+)
+
+func TestSVG(t *testing.T) {
+	series := []Series{
+		{Label: "1d20", Analysis: dice.New(20).AnalyzeNSeeded(1000, 1, 1)},
+		{Label: "advantage", Analysis: dice.New(20).Advantage().AnalyzeNSeeded(1000, 1, 1)},
+	}
+	tests := []struct {
+		opts          SVGOptions
+		width, height int
+	}{
+		{SVGOptions{}, 640, 400},
+		{SVGOptions{Width: 300, Height: 200, AtLeast: true}, 300, 200},
+
+		// Images too small for the margins are enlarged to fit a plot
+		{SVGOptions{Width: 10, Height: 10}, marginLeft + marginRight + minPlotSize, marginTop + marginBottom + minPlotSize},
+		{SVGOptions{Width: marginLeft + marginRight, Height: 1}, marginLeft + marginRight + minPlotSize, marginTop + marginBottom + minPlotSize},
+	}
+
+	for _, test := range tests {
+		b := &strings.Builder{}
+		if err := SVG(b, series, test.opts); err != nil {
+			t.Errorf("%+v: %v", test.opts, err)
+			continue
+		}
+		svg := b.String()
+		if size := fmt.Sprintf(`width="%d" height="%d"`, test.width, test.height); !strings.Contains(svg, size) {
+			t.Errorf("%+v: expected an image with %s", test.opts, size)
+		}
+		if strings.Contains(svg, "NaN") || strings.Contains(svg, "Inf") {
+			t.Errorf("%+v: image has invalid coordinates", test.opts)
+		}
+
+		// Every value from 1 to 20 can't be labelled in a narrow plot, but
+		// there should always be a few
+		labels := strings.Count(svg, `text-anchor="middle">`)
+		if labels < 2 || labels > 21 {
+			t.Errorf("%+v: %d x axis labels", test.opts, labels)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Package render draws the distributions of Analysis objects as charts, either
+as text for a terminal or as a standalone SVG image.
+*/
+package render
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/flywingedai/dice/core"
+)
+
+// Options for Text.
+type TextOptions struct {
+
+	// The width of the longest bar, in characters. Defaults to 40.
+	Width int
+
+	// Draw the bars with "#" instead of Unicode block characters.
+	ASCII bool
+}
+
+// Partial blocks for drawing bars to an eighth of a character
+var eighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+/*
+Write a bar chart of an analysis, with a row for every rolled value showing
+the percent of rolls of that value, the percent of rolls of at least that
+value and a bar scaled so the most common value fills "Width".
+
+	Value  Percent  At least
+	    3    0.46%   100.00%  ▍
+	    4    1.39%    99.54%  █▎
+*/
+func Text(w io.Writer, A *core.Analysis, opts TextOptions) error {
+	width := opts.Width
+	if width <= 0 {
+		width = 40
+	}
+
+	// The most common value fills the whole width
+	most := 0
+	for _, count := range A.Rolls {
+		most = max(most, count)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Value\tPercent\tAt least\t")
+	atLeast := A.N
+	for _, point := range A.CDF() {
+		count := A.Rolls[point.Value]
+		fmt.Fprintf(tw, "%d\t%.2f%%\t%.2f%%\t  %s\n",
+			point.Value,
+			percent(count, A.N),
+			percent(atLeast, A.N),
+			bar(float64(count)/float64(most)*float64(width), opts.ASCII))
+		atLeast -= count
+	}
+	return tw.Flush()
+}
+
+// The percent a count is of a total, or 0 for an empty total
+func percent(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total) * 100
+}
+
+// A bar "length" characters long, rounded to the nearest eighth
+func bar(length float64, ascii bool) string {
+	if ascii {
+		return strings.Repeat("#", int(math.Round(length)))
+	}
+	eights := int(math.Round(length * 8))
+	return strings.Repeat("█", eights/8) + eighths[eights%8]
+}
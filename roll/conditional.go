@@ -0,0 +1,99 @@
+package roll
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/flywingedai/dice/core"
+)
+
+/*
+Rolls the first child as a test, then rolls the child for the first branch
+the test's total matches. Branch i is rolled by child i+1. If there is one
+more child than there are branches, the last child is rolled when no branch
+matches, otherwise nothing is rolled and the branch counts as 0.
+
+Only the selected branch is rolled. The result's "Results" holds the test and
+then the branch. The tags of both are kept on the result, so a test made with
+CriticalOn(20) still shows up as a critical on a natural 20.
+*/
+type roll_Conditional struct {
+	Branches []core.Branch `json:"branches"`
+}
+
+func (r *roll_Conditional) Load(params map[string]interface{}) error {
+	return core.SetParams(r, params)
+}
+
+func (r *roll_Conditional) ValidateChildren(count int) error {
+	if count != len(r.Branches)+1 && count != len(r.Branches)+2 {
+		return fmt.Errorf("%w: expected %d or %d children for %d branches, got %d", core.ErrInvalidChildren, len(r.Branches)+1, len(r.Branches)+2, len(r.Branches), count)
+	}
+	return nil
+}
+
+// The index of the child to roll for a test total, or -1 for nothing
+func (r *roll_Conditional) selectChild(total, children int) int {
+	for i, branch := range r.Branches {
+		if branch.Matches(total) {
+			return i + 1
+		}
+	}
+	if children == len(r.Branches)+2 {
+		return children - 1
+	}
+	return -1
+}
+
+// The test plus the most expensive branch
+func (r *roll_Conditional) Cost(children []int) int {
+	return core.AddCost(children[0], slices.Max(append([]int{0}, children[1:]...)))
+}
+
+func (r *roll_Conditional) Roll(source core.RNG, definitions []*core.Definition) *core.Result {
+	test := definitions[0].Roll()
+
+	branch := &core.Result{
+		Base:   true,
+		Values: []int{},
+		Total:  0,
+	}
+	if i := r.selectChild(test.Total, len(definitions)); i >= 0 {
+		branch = definitions[i].Roll()
+	}
+
+	result := &core.Result{
+		Base:    false,
+		Results: []*core.Result{test, branch},
+		Values:  []int{},
+		Total:   0,
+	}
+	result.Inherit(test)
+	return result
+}
+
+func (r *roll_Conditional) Outcomes(children []*core.Distribution) ([]*core.Outcome, error) {
+	outcomes := []*core.Outcome{}
+	for _, test := range children[0].Values() {
+		branch := core.NewDistribution(map[int]float64{0: 1})
+		if i := r.selectChild(test, len(children)); i >= 0 {
+			branch = children[i]
+		}
+
+		for _, value := range branch.Values() {
+			outcomes = append(outcomes, &core.Outcome{
+				Probability: children[0].Probabilities[test] * branch.Probabilities[value],
+				Result: &core.Result{
+					Base: false,
+					Results: []*core.Result{
+						{Base: true, Values: []int{test}, Total: test},
+						{Base: true, Values: []int{value}, Total: value},
+					},
+					Values: []int{},
+					Total:  0,
+				},
+			})
+		}
+	}
+	return outcomes, nil
+}
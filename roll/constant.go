@@ -0,0 +1,36 @@
+package roll
+
+import "github.com/flywingedai/dice/core"
+
+// Always rolls the same value. Used for flat modifiers like the "+3" in
+// "1d8 + 3".
+type roll_Constant struct {
+	Value int `json:"value"`
+}
+
+func (r *roll_Constant) Load(params map[string]interface{}) error {
+	return core.SetParams(r, params)
+}
+
+func (r *roll_Constant) ValidateChildren(count int) error {
+	return noChildren(count)
+}
+
+func (r *roll_Constant) Roll(_ core.RNG, _ []*core.Definition) *core.Result {
+	return &core.Result{
+		Base:   true,
+		Values: []int{r.Value},
+		Total:  r.Value,
+	}
+}
+
+func (r *roll_Constant) Outcomes(_ []*core.Distribution) ([]*core.Outcome, error) {
+	return []*core.Outcome{{
+		Probability: 1,
+		Result: &core.Result{
+			Base:   true,
+			Values: []int{r.Value},
+			Total:  r.Value,
+		},
+	}}, nil
+}
@@ -0,0 +1,352 @@
+package roll
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/flywingedai/dice/core"
+)
+
+/*
+Rolls the child definition, and keeps rolling it again while the last roll was
+at least "threshold", up to "maxDepth" extra rolls. Every roll is kept in the
+result's "Results" so the chain can be inspected afterward.
+
+When "penetrate" is set, every extra roll is worth one less than was rolled,
+although the raw roll is still used to check the threshold.
+*/
+type roll_Explode struct {
+	Threshold int  `json:"threshold"`
+	MaxDepth  int  `json:"maxDepth"`
+	Penetrate bool `json:"penetrate"`
+}
+
+func (r *roll_Explode) Load(params map[string]interface{}) error {
+	if err := core.SetParams(r, params); err != nil {
+		return err
+	}
+	if r.MaxDepth <= 0 {
+		return fmt.Errorf("%w: maxDepth must be positive, got %d", core.ErrInvalidParams, r.MaxDepth)
+	}
+	return nil
+}
+
+func (r *roll_Explode) ValidateChildren(count int) error {
+	return exactChildren(count, 1)
+}
+
+// The first roll plus every extra roll
+func (r *roll_Explode) Cost(children []int) int {
+	return core.MulCost(r.MaxDepth+1, children[0])
+}
+
+func (r *roll_Explode) Roll(source core.RNG, definitions []*core.Definition) *core.Result {
+	result := &core.Result{
+		Base:    false,
+		Results: []*core.Result{},
+		Values:  []int{},
+		Total:   0,
+	}
+
+	roll := definitions[0].Roll()
+	result.Results = append(result.Results, roll)
+	exploded := roll.Total >= r.Threshold
+
+	for depth := 0; depth < r.MaxDepth && exploded; depth++ {
+		roll = definitions[0].Roll()
+		result.Results = append(result.Results, roll)
+
+		// Check the threshold against the raw roll before penetrating. The
+		// raw roll is still available in the sub-result's "Values".
+		exploded = roll.Total >= r.Threshold
+		if r.Penetrate {
+			roll.Total -= 1
+		}
+	}
+
+	return result
+}
+
+/*
+The outcomes of an explosion are built up one extra roll at a time. Only the
+total of each chain is kept, as a single sub-result, so the number of outcomes
+stays bounded by the range of possible totals rather than growing with every
+extra roll.
+*/
+func (r *roll_Explode) Outcomes(children []*core.Distribution) ([]*core.Outcome, error) {
+	child := children[0]
+
+	// Totals for chains that have stopped, and chains that are still exploding
+	stopped := map[int]float64{}
+	exploding := map[int]float64{0: 1}
+
+	for depth := 0; depth <= r.MaxDepth && len(exploding) > 0; depth++ {
+		next := map[int]float64{}
+		for total, p := range exploding {
+			for value, pv := range child.Probabilities {
+				rolled := value
+				if r.Penetrate && depth > 0 {
+					rolled -= 1
+				}
+				if value >= r.Threshold && depth < r.MaxDepth {
+					next[total+rolled] += p * pv
+				} else {
+					stopped[total+rolled] += p * pv
+				}
+			}
+		}
+		exploding = next
+	}
+
+	outcomes := []*core.Outcome{}
+	for total, p := range stopped {
+		outcomes = append(outcomes, &core.Outcome{
+			Probability: p,
+			Result: &core.Result{
+				Base: false,
+				Results: []*core.Result{{
+					Base:   true,
+					Values: []int{total},
+					Total:  total,
+				}},
+				Values: []int{},
+				Total:  0,
+			},
+		})
+	}
+	return outcomes, nil
+}
+
+/*
+Rolls the child definition "count" times, and rolls an extra die whenever a
+roll was at least "threshold", up to "maxDepth" extra rolls for each of the
+original dice. Unlike roll_Explode, every roll is its own sub-result in the
+result's "Results", so the extra dice join the pool and can be kept, dropped
+or counted on their own.
+
+When "penetrate" is set, every extra roll is worth one less than was rolled,
+although the raw roll is still used to check the threshold.
+*/
+type roll_ExplodePool struct {
+	Count     int  `json:"count"`
+	Threshold int  `json:"threshold"`
+	MaxDepth  int  `json:"maxDepth"`
+	Penetrate bool `json:"penetrate"`
+}
+
+func (r *roll_ExplodePool) Load(params map[string]interface{}) error {
+	if err := core.SetParams(r, params); err != nil {
+		return err
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("%w: count must not be negative, got %d", core.ErrInvalidParams, r.Count)
+	}
+	if r.MaxDepth <= 0 {
+		return fmt.Errorf("%w: maxDepth must be positive, got %d", core.ErrInvalidParams, r.MaxDepth)
+	}
+	return nil
+}
+
+func (r *roll_ExplodePool) ValidateChildren(count int) error {
+	return exactChildren(count, 1)
+}
+
+// Every die plus every extra roll it is allowed
+func (r *roll_ExplodePool) Cost(children []int) int {
+	return core.MulCost(r.Count, core.MulCost(r.MaxDepth+1, children[0]))
+}
+
+func (r *roll_ExplodePool) Roll(source core.RNG, definitions []*core.Definition) *core.Result {
+	result := &core.Result{
+		Base:    false,
+		Results: []*core.Result{},
+		Values:  []int{},
+		Total:   0,
+	}
+
+	for i := 0; i < r.Count; i++ {
+		roll := definitions[0].Roll()
+		result.Results = append(result.Results, roll)
+		exploded := roll.Total >= r.Threshold
+
+		for depth := 0; depth < r.MaxDepth && exploded; depth++ {
+			roll = definitions[0].Roll()
+			result.Results = append(result.Results, roll)
+
+			// Same as roll_Explode, the threshold uses the raw roll
+			exploded = roll.Total >= r.Threshold
+			if r.Penetrate {
+				roll.Total -= 1
+			}
+		}
+	}
+
+	return result
+}
+
+/*
+The chains of rolls each die can make are enumerated first, and then every
+multiset of chains across the dice, weighted by the number of orderings it
+represents, the same way identical parts are enumerated for roll_Multiple.
+Each roll stays a separate sub-result, so the number of outcomes grows quickly
+with the count and depth, and pools with more than core.MaxOutcomes outcomes
+return an error before enumerating any of them. Summing and counting pools
+use PoolSum() instead, which doesn't need to enumerate them.
+*/
+func (r *roll_ExplodePool) Outcomes(children []*core.Distribution) ([]*core.Outcome, error) {
+	child := children[0]
+
+	// Count the chains before building any of them. Each of the first
+	// "maxDepth" rolls ends the chain on a face below the threshold, and the
+	// last roll ends it on any face.
+	exploding := 0.0
+	for value := range child.Probabilities {
+		if value >= r.Threshold {
+			exploding++
+		}
+	}
+	faces := float64(len(child.Probabilities))
+	count, paths := 0.0, 1.0
+	for depth := 0; depth < r.MaxDepth && count <= float64(core.MaxOutcomes); depth++ {
+		count += paths * (faces - exploding)
+		paths *= exploding
+	}
+	count += paths * faces
+	if count > float64(core.MaxOutcomes) || binomial(int(count)+r.Count-1, r.Count) > float64(core.MaxOutcomes) {
+		return nil, fmt.Errorf("more than %d outcomes to enumerate", core.MaxOutcomes)
+	}
+
+	chains := []*core.Outcome{}
+	r.chains(child, 0, 1, []int{}, &chains)
+
+	outcomes := []*core.Outcome{}
+	combineChains(chains, r.Count, 1, []*core.Result{}, &outcomes)
+	return outcomes, nil
+}
+
+/*
+Each die's chain is built up one roll at a time, like roll_Explode.Outcomes,
+adding up what each roll is worth rather than keeping the rolls. The dice are
+then added together by repeatedly doubling the chain's distribution, so even
+large pools only take a few convolutions.
+*/
+func (r *roll_ExplodePool) PoolSum(children []*core.Distribution, worth func(total int) int) *core.Distribution {
+	child := children[0]
+
+	// Work out what every raw and penetrated roll is worth up front
+	worths := map[int]int{}
+	for value := range child.Probabilities {
+		worths[value] = worth(value)
+		if r.Penetrate {
+			worths[value-1] = worth(value - 1)
+		}
+	}
+
+	// Totals for chains that have stopped, and chains that are still exploding
+	stopped := map[int]float64{}
+	exploding := map[int]float64{0: 1}
+
+	for depth := 0; depth <= r.MaxDepth && len(exploding) > 0; depth++ {
+		next := map[int]float64{}
+		for total, p := range exploding {
+			for value, pv := range child.Probabilities {
+				rolled := value
+				if r.Penetrate && depth > 0 {
+					rolled -= 1
+				}
+				if value >= r.Threshold && depth < r.MaxDepth {
+					next[total+worths[rolled]] += p * pv
+				} else {
+					stopped[total+worths[rolled]] += p * pv
+				}
+			}
+		}
+		exploding = next
+	}
+
+	die := core.NewDistribution(stopped)
+	pool := core.NewDistribution(map[int]float64{0: 1})
+	for n := r.Count; n > 0; n /= 2 {
+		if n%2 == 1 {
+			pool = core.Convolve(pool, die)
+		}
+		if n > 1 {
+			die = core.Convolve(die, die)
+		}
+	}
+	return pool
+}
+
+// Recursively build every chain of rolls a single die can make, as an outcome
+// holding one base sub-result for each roll in the chain
+func (r *roll_ExplodePool) chains(child *core.Distribution, depth int, probability float64, totals []int, chains *[]*core.Outcome) {
+	for _, value := range child.Values() {
+		rolled := value
+		if r.Penetrate && depth > 0 {
+			rolled -= 1
+		}
+		next := append(append([]int{}, totals...), rolled)
+		p := probability * child.Probabilities[value]
+
+		if value >= r.Threshold && depth < r.MaxDepth {
+			r.chains(child, depth+1, p, next, chains)
+			continue
+		}
+
+		results := []*core.Result{}
+		for _, total := range next {
+			results = append(results, &core.Result{
+				Base:   true,
+				Values: []int{total},
+				Total:  total,
+			})
+		}
+		*chains = append(*chains, &core.Outcome{Probability: p, Result: newPool(results)})
+	}
+}
+
+// Recursively choose how many of the remaining dice roll each chain, keeping
+// the sub-results of the chosen chains together in one pool
+func combineChains(chains []*core.Outcome, remaining int, probability float64, results []*core.Result, outcomes *[]*core.Outcome) {
+	if remaining == 0 || len(chains) == 1 {
+		for i := 0; i < remaining; i++ {
+			results = append(results, chains[0].Result.Results...)
+		}
+		if remaining > 0 {
+			probability *= math.Pow(chains[0].Probability, float64(remaining))
+		}
+		*outcomes = append(*outcomes, &core.Outcome{Probability: probability, Result: newPool(results)})
+		return
+	}
+
+	for count := 0; count <= remaining; count++ {
+		next := append([]*core.Result{}, results...)
+		for i := 0; i < count; i++ {
+			next = append(next, chains[0].Result.Results...)
+		}
+		weight := binomial(remaining, count) * math.Pow(chains[0].Probability, float64(count))
+		combineChains(chains[1:], remaining-count, probability*weight, next, outcomes)
+	}
+}
+
+// The binomial coefficient n choose k, as a float to avoid overflow
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result *= float64(n-k+i) / float64(i)
+	}
+	return result
+}
+
+// An unaggregated result holding the rolls of a pool
+func newPool(results []*core.Result) *core.Result {
+	return &core.Result{
+		Base:    false,
+		Results: results,
+		Values:  []int{},
+		Total:   0,
+	}
+}
@@ -0,0 +1,162 @@
+package roll
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/flywingedai/dice/core"
+)
+
+func init() {
+	Initialize()
+}
+
+// An RNG making a fixed sequence of rolls on a die
+type script struct {
+	rolls []int
+}
+
+func (s *script) Intn(n int) int {
+	roll := s.rolls[0]
+	s.rolls = s.rolls[1:]
+	return roll - 1
+}
+
+// A d6 rolling the given values in order
+func scriptedD6(rolls ...int) []*core.Definition {
+	d6 := &core.Definition{RollType: core.ROLL_SIDES, RollParams: map[string]interface{}{"sides": 6}}
+	return []*core.Definition{d6.SetSource(&script{rolls: rolls})}
+}
+
+func d6Distribution() *core.Distribution {
+	probabilities := map[int]float64{}
+	for value := 1; value <= 6; value++ {
+		probabilities[value] = 1.0 / 6
+	}
+	return core.NewDistribution(probabilities)
+}
+
+// The totals of each sub-result
+func totals(result *core.Result) []int {
+	values := []int{}
+	for _, r := range result.Results {
+		values = append(values, r.Total)
+	}
+	return values
+}
+
+func TestExplodeRoll(t *testing.T) {
+	tests := []struct {
+		name  string
+		roll  core.Roll
+		rolls []int
+		want  []int
+	}{
+		{"explode", &roll_Explode{Threshold: 6, MaxDepth: 100}, []int{6, 6, 3}, []int{6, 6, 3}},
+		{"explode no extra roll", &roll_Explode{Threshold: 6, MaxDepth: 100}, []int{5}, []int{5}},
+		{"explode threshold", &roll_Explode{Threshold: 5, MaxDepth: 100}, []int{5, 6, 4}, []int{5, 6, 4}},
+		{"explode penetrating", &roll_Explode{Threshold: 6, MaxDepth: 100, Penetrate: true}, []int{6, 6, 3}, []int{6, 5, 2}},
+		{"explode max depth", &roll_Explode{Threshold: 6, MaxDepth: 1}, []int{6, 6, 6}, []int{6, 6}},
+		{"explode penetrating max depth", &roll_Explode{Threshold: 6, MaxDepth: 2, Penetrate: true}, []int{6, 6, 6, 6}, []int{6, 5, 5}},
+
+		{"pool", &roll_ExplodePool{Count: 2, Threshold: 6, MaxDepth: 100}, []int{6, 2, 5}, []int{6, 2, 5}},
+		{"pool threshold", &roll_ExplodePool{Count: 3, Threshold: 5, MaxDepth: 100}, []int{1, 5, 3, 6, 6, 1}, []int{1, 5, 3, 6, 6, 1}},
+		{"pool penetrating", &roll_ExplodePool{Count: 2, Threshold: 6, MaxDepth: 100, Penetrate: true}, []int{6, 6, 2, 4}, []int{6, 5, 1, 4}},
+		{"pool max depth", &roll_ExplodePool{Count: 2, Threshold: 6, MaxDepth: 1}, []int{6, 6, 6, 4}, []int{6, 6, 6, 4}},
+		{"pool penetrating max depth", &roll_ExplodePool{Count: 2, Threshold: 6, MaxDepth: 1, Penetrate: true}, []int{6, 6, 3}, []int{6, 5, 3}},
+		{"pool of none", &roll_ExplodePool{Count: 0, Threshold: 6, MaxDepth: 100}, []int{}, []int{}},
+	}
+
+	for _, test := range tests {
+		definitions := scriptedD6(test.rolls...)
+		result := test.roll.Roll(nil, definitions)
+		if got := totals(result); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: rolled %v, want %v", test.name, got, test.want)
+		}
+
+		// The raw rolls are kept on the sub-results even when penetrating
+		for i, r := range result.Results {
+			if r.Values[0] != test.rolls[i] {
+				t.Errorf("%s: roll %d has values %v, want %d", test.name, i, r.Values, test.rolls[i])
+			}
+		}
+	}
+}
+
+func TestExplodeOutcomes(t *testing.T) {
+	tests := []struct {
+		name          string
+		roll          core.ExactRoll
+		mean          float64
+		probabilities map[int]float64
+	}{
+		{"explode once", &roll_Explode{Threshold: 6, MaxDepth: 1}, 3.5 + 3.5/6, map[int]float64{5: 1.0 / 6, 6: 0, 7: 1.0 / 36, 12: 1.0 / 36}},
+		{"explode penetrating once", &roll_Explode{Threshold: 6, MaxDepth: 1, Penetrate: true}, 3.5 + 2.5/6, map[int]float64{6: 1.0 / 36, 11: 1.0 / 36, 12: 0}},
+		{"explode twice", &roll_Explode{Threshold: 6, MaxDepth: 2}, 3.5 + 3.5/6 + 3.5/36, map[int]float64{18: 1.0 / 216, 13: 1.0 / 216, 12: 0}},
+		{"explode threshold", &roll_Explode{Threshold: 5, MaxDepth: 1}, 3.5 + 3.5/3, map[int]float64{4: 1.0 / 6, 5: 0, 6: 1.0 / 36}},
+
+		{"pool once", &roll_ExplodePool{Count: 1, Threshold: 6, MaxDepth: 1}, 3.5 + 3.5/6, map[int]float64{6: 0, 12: 1.0 / 36}},
+		{"pool penetrating once", &roll_ExplodePool{Count: 2, Threshold: 6, MaxDepth: 1, Penetrate: true}, 2 * (3.5 + 2.5/6), map[int]float64{22: 1.0 / 1296}},
+		{"pool twice", &roll_ExplodePool{Count: 2, Threshold: 6, MaxDepth: 2}, 2 * (3.5 + 3.5/6 + 3.5/36), map[int]float64{2: 1.0 / 36, 36: math.Pow(1.0/6, 6)}},
+	}
+
+	for _, test := range tests {
+		outcomes, err := test.roll.Outcomes([]*core.Distribution{d6Distribution()})
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		// Sum each outcome, as the sum aggregation would
+		probabilities := map[int]float64{}
+		for _, outcome := range outcomes {
+			total := 0
+			for _, r := range outcome.Result.Results {
+				total += r.Total
+			}
+			probabilities[total] += outcome.Probability
+		}
+		checkDistribution(t, test.name, core.NewDistribution(probabilities), test.mean, test.probabilities)
+
+		// Pools can also be summed directly, which must agree
+		if pool, ok := test.roll.(core.PoolRoll); ok {
+			sum := pool.PoolSum([]*core.Distribution{d6Distribution()}, func(total int) int { return total })
+			checkDistribution(t, test.name+" PoolSum", sum, test.mean, probabilities)
+		}
+	}
+}
+
+func TestExplodePoolSumWorth(t *testing.T) {
+
+	// Count the 5s and 6s of 3d6 exploding on a 6. Each die makes 1/5 extra
+	// rolls on average, which are penetrated so only a 6 still succeeds.
+	pool := &roll_ExplodePool{Count: 3, Threshold: 6, MaxDepth: 100, Penetrate: true}
+	worth := func(total int) int {
+		if total >= 5 {
+			return 1
+		}
+		return 0
+	}
+	successes := 1.0/3 + 1.0/5*(1.0/6)
+	checkDistribution(t, "successes", pool.PoolSum([]*core.Distribution{d6Distribution()}, worth), 3*successes, map[int]float64{0: math.Pow(2.0/3, 3)})
+}
+
+func TestExplodePoolTooManyOutcomes(t *testing.T) {
+	pool := &roll_ExplodePool{Count: 20, Threshold: 6, MaxDepth: 100}
+	if _, err := pool.Outcomes([]*core.Distribution{d6Distribution()}); err == nil {
+		t.Errorf("expected an error enumerating 20d6!")
+	}
+}
+
+func checkDistribution(t *testing.T, name string, distribution *core.Distribution, mean float64, probabilities map[int]float64) {
+	t.Helper()
+	if math.Abs(distribution.Mean-mean) > 1e-9 {
+		t.Errorf("%s: mean = %v, want %v", name, distribution.Mean, mean)
+	}
+	for value, want := range probabilities {
+		if got := distribution.Probabilities[value]; math.Abs(got-want) > 1e-12 {
+			t.Errorf("%s: P(%d) = %v, want %v", name, value, got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package roll
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flywingedai/dice/core"
+)
+
+/*
+Rolls one of the listed faces, each with the same chance. Faces can be listed
+more than once, like the 3s and 4s on an average die. The face's symbols are
+copied onto the result's "Symbols". Symbol names must not be empty or contain
+"," or "=", so that they can be encoded by core.EncodeSymbols().
+*/
+type roll_Faces struct {
+	Faces []core.Face `json:"faces"`
+}
+
+func (r *roll_Faces) Load(params map[string]interface{}) error {
+	if err := core.SetParams(r, params); err != nil {
+		return err
+	}
+	if len(r.Faces) == 0 {
+		return fmt.Errorf("%w: faces must not be empty", core.ErrInvalidParams)
+	}
+
+	// Symbol names have to survive core.EncodeSymbols, which separates them
+	// with "," and "="
+	for _, face := range r.Faces {
+		for symbol := range face.Symbols {
+			if symbol == "" || strings.ContainsAny(symbol, ",=") {
+				return fmt.Errorf("%w: symbol %q must be non-empty and not contain \",\" or \"=\"", core.ErrInvalidParams, symbol)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *roll_Faces) ValidateChildren(count int) error {
+	return noChildren(count)
+}
+
+func (r *roll_Faces) Roll(source core.RNG, _ []*core.Definition) *core.Result {
+	return r.result(r.Faces[source.Intn(len(r.Faces))])
+}
+
+func (r *roll_Faces) Outcomes(_ []*core.Distribution) ([]*core.Outcome, error) {
+	if len(r.Faces) > core.MaxOutcomes {
+		return nil, fmt.Errorf("more than %d outcomes to enumerate", core.MaxOutcomes)
+	}
+	outcomes := []*core.Outcome{}
+	for _, face := range r.Faces {
+		outcomes = append(outcomes, &core.Outcome{
+			Probability: 1.0 / float64(len(r.Faces)),
+			Result:      r.result(face),
+		})
+	}
+	return outcomes, nil
+}
+
+// Create the base result for a face
+func (r *roll_Faces) result(face core.Face) *core.Result {
+	result := &core.Result{
+		Base:   true,
+		Values: []int{face.Value},
+		Total:  face.Value,
+	}
+	for symbol, count := range face.Symbols {
+		result.AddSymbol(symbol, count)
+	}
+	return result
+}
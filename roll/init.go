@@ -1,16 +1,59 @@
 package roll
 
-import "github.com/flywingedai/dice/core"
+import (
+	"fmt"
+
+	"github.com/flywingedai/dice/core"
+)
 
 func Initialize() {
 
 	// Creation
 	core.AddRollType(core.ROLL_SIDES, func() core.Roll { return &roll_Sides{} })
 	core.AddRollType(core.ROLL_WEIGHTED, func() core.Roll { return &roll_Weighted{} })
+	core.AddRollType(core.ROLL_CONSTANT, func() core.Roll { return &roll_Constant{} })
+	core.AddRollType(core.ROLL_FACES, func() core.Roll { return &roll_Faces{} })
 
 	// Rolls
 	core.AddRollType(core.ROLL_MULTIPLE, func() core.Roll { return &roll_Multiple{} })
+	core.AddRollType(core.ROLL_EXPLODE, func() core.Roll { return &roll_Explode{} })
+	core.AddRollType(core.ROLL_EXPLODE_POOL, func() core.Roll { return &roll_ExplodePool{} })
+	core.AddRollType(core.ROLL_REROLL, func() core.Roll { return &roll_Reroll{} })
 
 	// Skip for Merge
 	core.AddRollType(core.ROLL_SKIP, func() core.Roll { return &roll_Skip{} })
+
+	// Opposed
+	core.AddRollType(core.ROLL_OPPOSED, func() core.Roll { return &roll_Opposed{} })
+
+	// Branching
+	core.AddRollType(core.ROLL_CONDITIONAL, func() core.Roll { return &roll_Conditional{} })
+}
+
+/////////////
+// HELPERS //
+/////////////
+
+// Used by base rolls, which never roll any children
+func noChildren(count int) error {
+	return exactChildren(count, 0)
+}
+
+// Tag a base result as a critical or a fumble based on its total. Either
+// threshold may be nil, in which case that tag is never added.
+func tag(result *core.Result, critical, fumble *int) {
+	if critical != nil && result.Total >= *critical {
+		result.AddTag(core.TAG_CRITICAL, 1)
+	}
+	if fumble != nil && result.Total <= *fumble {
+		result.AddTag(core.TAG_FUMBLE, 1)
+	}
+}
+
+// Used by rolls which need an exact number of children
+func exactChildren(count, expected int) error {
+	if count != expected {
+		return fmt.Errorf("%w: expected %d children, got %d", core.ErrInvalidChildren, expected, count)
+	}
+	return nil
 }
@@ -1,7 +1,7 @@
 package roll
 
 import (
-	"math/rand"
+	"fmt"
 
 	"github.com/flywingedai/dice/core"
 )
@@ -10,11 +10,21 @@ type roll_Multiple struct {
 	Count int `json:"count"`
 }
 
-func (r *roll_Multiple) Load(params map[string]interface{}) {
-	core.SetParams(r, params)
+func (r *roll_Multiple) Load(params map[string]interface{}) error {
+	if err := core.SetParams(r, params); err != nil {
+		return err
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("%w: count must not be negative, got %d", core.ErrInvalidParams, r.Count)
+	}
+	return nil
+}
+
+func (r *roll_Multiple) ValidateChildren(count int) error {
+	return exactChildren(count, 1)
 }
 
-func (r *roll_Multiple) Roll(source *rand.Rand, definitions []*core.Definition) *core.Result {
+func (r *roll_Multiple) Roll(source core.RNG, definitions []*core.Definition) *core.Result {
 	result := &core.Result{
 		Base:    false,
 		Results: []*core.Result{},
@@ -28,3 +38,20 @@ func (r *roll_Multiple) Roll(source *rand.Rand, definitions []*core.Definition)
 
 	return result
 }
+
+func (r *roll_Multiple) ResultCount(_ int) int {
+	return r.Count
+}
+
+func (r *roll_Multiple) Cost(children []int) int {
+	return core.MulCost(r.Count, children[0])
+}
+
+// Every roll is an independent copy of the same child
+func (r *roll_Multiple) Parts(children []*core.Distribution) []*core.Distribution {
+	parts := []*core.Distribution{}
+	for i := 0; i < r.Count; i++ {
+		parts = append(parts, children[0])
+	}
+	return parts
+}
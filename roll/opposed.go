@@ -0,0 +1,37 @@
+package roll
+
+import "github.com/flywingedai/dice/core"
+
+/*
+Rolls an attacker and a defender, which are the first and second children.
+This works like roll_Skip, but insists on exactly two children so the
+aggregation always knows which side is which.
+*/
+type roll_Opposed struct{}
+
+func (r *roll_Opposed) Load(params map[string]interface{}) error {
+	return core.SetParams(r, params)
+}
+
+func (r *roll_Opposed) ValidateChildren(count int) error {
+	return exactChildren(count, 2)
+}
+
+// The attacker and then the defender
+func (r *roll_Opposed) ResultCount(_ int) int {
+	return 2
+}
+
+func (r *roll_Opposed) Roll(source core.RNG, definitions []*core.Definition) *core.Result {
+	return &core.Result{
+		Base:    false,
+		Results: []*core.Result{definitions[0].Roll(), definitions[1].Roll()},
+		Values:  []int{},
+		Total:   0,
+	}
+}
+
+// The attacker and defender are rolled independently
+func (r *roll_Opposed) Parts(children []*core.Distribution) []*core.Distribution {
+	return children
+}
@@ -0,0 +1,113 @@
+package roll
+
+import "github.com/flywingedai/dice/core"
+
+// The most rerolls a roll_Reroll will make when not limited to once, so a
+// condition that matches every face can't loop forever.
+const rerollLimit = 100
+
+/*
+Rolls the child definition, and rolls it again whenever the total matches the
+condition. With "once" set only a single reroll is made, and the new roll is
+kept even if it matches the condition again. Every roll, including the
+discarded ones, is kept in the result's "Results" with the kept roll last.
+*/
+type roll_Reroll struct {
+	Condition core.Condition `json:"condition"`
+	Once      bool           `json:"once"`
+}
+
+func (r *roll_Reroll) Load(params map[string]interface{}) error {
+	if err := core.SetParams(r, params); err != nil {
+		return err
+	}
+	return r.Condition.Validate()
+}
+
+func (r *roll_Reroll) ValidateChildren(count int) error {
+	return exactChildren(count, 1)
+}
+
+// The maximum number of rerolls
+func (r *roll_Reroll) limit() int {
+	if r.Once {
+		return 1
+	}
+	return rerollLimit
+}
+
+// The first roll plus every reroll
+func (r *roll_Reroll) Cost(children []int) int {
+	return core.MulCost(r.limit()+1, children[0])
+}
+
+func (r *roll_Reroll) Roll(source core.RNG, definitions []*core.Definition) *core.Result {
+	result := &core.Result{
+		Base:    false,
+		Results: []*core.Result{},
+		Values:  []int{},
+		Total:   0,
+	}
+
+	roll := definitions[0].Roll()
+	result.Results = append(result.Results, roll)
+
+	for rerolls := 0; rerolls < r.limit() && r.Condition.Matches(roll.Total); rerolls++ {
+		roll = definitions[0].Roll()
+		result.Results = append(result.Results, roll)
+	}
+
+	return result
+}
+
+/*
+Only the kept roll matters to the total, so each outcome has a single
+sub-result holding the kept roll. With at most L rerolls and a probability q of
+matching the condition, a value that doesn't match is kept after any of the
+first L rolls, or after the last one, while a matching value can only be kept
+from the last roll.
+*/
+func (r *roll_Reroll) Outcomes(children []*core.Distribution) ([]*core.Outcome, error) {
+	child := children[0]
+	L := float64(r.limit())
+
+	q := 0.0
+	for value, p := range child.Probabilities {
+		if r.Condition.Matches(value) {
+			q += p
+		}
+	}
+
+	// Chance of reaching the last roll, and the chance of stopping early
+	last := 1.0
+	for i := 0.0; i < L; i++ {
+		last *= q
+	}
+	early := 0.0
+	if q < 1 {
+		early = (1 - last) / (1 - q)
+	}
+
+	outcomes := []*core.Outcome{}
+	for _, value := range child.Values() {
+		p := child.Probabilities[value] * last
+		if !r.Condition.Matches(value) {
+			p += child.Probabilities[value] * early
+		}
+
+		outcomes = append(outcomes, &core.Outcome{
+			Probability: p,
+			Result: &core.Result{
+				Base: false,
+				Results: []*core.Result{{
+					Base:   true,
+					Values: []int{value},
+					Total:  value,
+				}},
+				Values: []int{},
+				Total:  0,
+			},
+		})
+	}
+	return outcomes, nil
+}
@@ -1,24 +1,61 @@
 package roll
 
 import (
-	"math/rand"
+	"fmt"
 
 	"github.com/flywingedai/dice/core"
 )
 
+/*
+Rolls a value from 1 to "sides". If "critical" is set, rolls of at least it are
+tagged with TAG_CRITICAL, and if "fumble" is set, rolls of at most it are
+tagged with TAG_FUMBLE. Nothing is tagged by default.
+*/
 type roll_Sides struct {
-	Sides int `json:"sides"`
+	Sides    int  `json:"sides"`
+	Critical *int `json:"critical,omitempty"`
+	Fumble   *int `json:"fumble,omitempty"`
 }
 
-func (r *roll_Sides) Load(params map[string]interface{}) {
-	core.SetParams(r, params)
+func (r *roll_Sides) Load(params map[string]interface{}) error {
+	if err := core.SetParams(r, params); err != nil {
+		return err
+	}
+	if r.Sides <= 0 {
+		return fmt.Errorf("%w: sides must be positive, got %d", core.ErrInvalidParams, r.Sides)
+	}
+	return nil
+}
+
+func (r *roll_Sides) ValidateChildren(count int) error {
+	return noChildren(count)
 }
 
-func (r *roll_Sides) Roll(source *rand.Rand, _ []*core.Definition) *core.Result {
+func (r *roll_Sides) Roll(source core.RNG, _ []*core.Definition) *core.Result {
 	value := source.Intn(r.Sides) + 1
-	return &core.Result{
+	result := &core.Result{
 		Base:   true,
 		Values: []int{value},
 		Total:  value,
 	}
+	tag(result, r.Critical, r.Fumble)
+	return result
+}
+
+func (r *roll_Sides) Outcomes(_ []*core.Distribution) ([]*core.Outcome, error) {
+	if r.Sides > core.MaxOutcomes {
+		return nil, fmt.Errorf("more than %d outcomes to enumerate", core.MaxOutcomes)
+	}
+	outcomes := []*core.Outcome{}
+	for value := 1; value <= r.Sides; value++ {
+		outcomes = append(outcomes, &core.Outcome{
+			Probability: 1.0 / float64(r.Sides),
+			Result: &core.Result{
+				Base:   true,
+				Values: []int{value},
+				Total:  value,
+			},
+		})
+	}
+	return outcomes, nil
 }
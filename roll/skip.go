@@ -1,18 +1,14 @@
 package roll
 
-import (
-	"math/rand"
-
-	"github.com/flywingedai/dice/core"
-)
+import "github.com/flywingedai/dice/core"
 
 type roll_Skip struct{}
 
-func (r *roll_Skip) Load(params map[string]interface{}) {
-	core.SetParams(r, params)
+func (r *roll_Skip) Load(params map[string]interface{}) error {
+	return core.SetParams(r, params)
 }
 
-func (r *roll_Skip) Roll(source *rand.Rand, definitions []*core.Definition) *core.Result {
+func (r *roll_Skip) Roll(source core.RNG, definitions []*core.Definition) *core.Result {
 	result := &core.Result{
 		Base:    false,
 		Results: []*core.Result{},
@@ -28,3 +24,13 @@ func (r *roll_Skip) Roll(source *rand.Rand, definitions []*core.Definition) *cor
 
 	return result
 }
+
+// One result for each definition
+func (r *roll_Skip) ResultCount(children int) int {
+	return children
+}
+
+// Each definition is rolled independently
+func (r *roll_Skip) Parts(children []*core.Distribution) []*core.Distribution {
+	return children
+}
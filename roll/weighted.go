@@ -1,33 +1,55 @@
 package roll
 
 import (
-	"math/rand"
+	"fmt"
 	"sort"
 
 	"github.com/flywingedai/dice/core"
 )
 
+/*
+Rolls one of the values in "weights", with a chance proportional to its weight.
+If "critical" is set, rolls of at least it are tagged with TAG_CRITICAL, and if
+"fumble" is set, rolls of at most it are tagged with TAG_FUMBLE. Nothing is
+tagged by default.
+*/
 type roll_Weighted struct {
-	Weights map[int]int `json:"weights"`
+	Weights  map[int]int `json:"weights"`
+	Critical *int        `json:"critical,omitempty"`
+	Fumble   *int        `json:"fumble,omitempty"`
 
 	values []int `json:"-"`
 	total  int   `json:"-"`
 }
 
-func (r *roll_Weighted) Load(params map[string]interface{}) {
-	core.SetParams(r, params)
+func (r *roll_Weighted) Load(params map[string]interface{}) error {
+	if err := core.SetParams(r, params); err != nil {
+		return err
+	}
 
 	r.total = 0
 	r.values = []int{}
 	for value, weight := range r.Weights {
+		if weight < 0 {
+			return fmt.Errorf("%w: weight for %d must not be negative, got %d", core.ErrInvalidParams, value, weight)
+		}
 		r.total += weight
 		r.values = append(r.values, value)
 	}
 	sort.SliceStable(r.values, func(i, j int) bool { return r.values[i] < r.values[j] })
 
+	if r.total <= 0 {
+		return fmt.Errorf("%w: weights must contain a positive weight", core.ErrInvalidParams)
+	}
+	return nil
+
+}
+
+func (r *roll_Weighted) ValidateChildren(count int) error {
+	return noChildren(count)
 }
 
-func (r *roll_Weighted) Roll(source *rand.Rand, _ []*core.Definition) *core.Result {
+func (r *roll_Weighted) Roll(source core.RNG, _ []*core.Definition) *core.Result {
 
 	randomChoice := source.Intn(r.total)
 
@@ -43,9 +65,29 @@ func (r *roll_Weighted) Roll(source *rand.Rand, _ []*core.Definition) *core.Resu
 		}
 	}
 
-	return &core.Result{
+	result := &core.Result{
 		Base:   true,
 		Values: []int{selectedValue},
 		Total:  selectedValue,
 	}
+	tag(result, r.Critical, r.Fumble)
+	return result
+}
+
+func (r *roll_Weighted) Outcomes(_ []*core.Distribution) ([]*core.Outcome, error) {
+	if len(r.values) > core.MaxOutcomes {
+		return nil, fmt.Errorf("more than %d outcomes to enumerate", core.MaxOutcomes)
+	}
+	outcomes := []*core.Outcome{}
+	for _, value := range r.values {
+		outcomes = append(outcomes, &core.Outcome{
+			Probability: float64(r.Weights[value]) / float64(r.total),
+			Result: &core.Result{
+				Base:   true,
+				Values: []int{value},
+				Total:  value,
+			},
+		})
+	}
+	return outcomes, nil
 }
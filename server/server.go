@@ -0,0 +1,391 @@
+/*
+Package server exposes rolling and analysis over HTTP, for services such as a
+virtual tabletop that roll on behalf of their users.
+
+	http.Handle("/dice/", http.StripPrefix("/dice", server.NewHandler(server.DefaultLimits)))
+
+The handler serves two endpoints, which both take a JSON body with either a
+"definition", in the same JSON shape as a *core.Definition, or "notation", as
+accepted by dice.Parse():
+
+	POST /roll     {"notation": "1d20+5", "times": 2, "seed": 42}
+	POST /analyze  {"definition": {...}, "n": 100000, "threads": 4, "seed": 42}
+
+/roll responds with {"results": [...]} holding a *core.Result for each roll,
+and /analyze responds with the *core.Analysis. Errors respond with
+{"error": "..."} and a 4xx or 5xx status. Every request is checked against the
+handler's Limits before anything is rolled.
+*/
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/flywingedai/dice"
+	"github.com/flywingedai/dice/core"
+)
+
+/*
+Limits on a single request, so one request can't exhaust the server. Fields
+left as 0 use the value from DefaultLimits.
+*/
+type Limits struct {
+
+	// The largest request body, in bytes.
+	MaxBodyBytes int64
+
+	// The longest "notation", in bytes. This is checked before it is parsed.
+	MaxNotationLength int
+
+	// The most nodes a definition can have, see Definition.Size().
+	MaxNodes int
+
+	// The most base rolls a single roll can make, see Definition.Cost().
+	MaxCost int
+
+	// The most rolls /roll can make in one request.
+	MaxTimes int
+
+	// The most rolls /analyze can make in one request, and the number made
+	// when the request doesn't set "n".
+	MaxN     int
+	DefaultN int
+
+	// The most base rolls /analyze can make in one request, which is "n"
+	// multiplied by the cost of the definition.
+	MaxWork int
+
+	// The most goroutines /analyze can use in one request.
+	MaxThreads int
+
+	// How long /analyze can run before giving up.
+	Timeout time.Duration
+}
+
+// The limits used for any field of Limits left as 0.
+var DefaultLimits = Limits{
+	MaxBodyBytes:      1 << 20,
+	MaxNotationLength: 1000,
+	MaxNodes:          1000,
+	MaxCost:           100_000,
+	MaxTimes:          100,
+	MaxN:              10_000_000,
+	DefaultN:          100_000,
+	MaxWork:           100_000_000,
+	MaxThreads:        runtime.NumCPU(),
+	Timeout:           10 * time.Second,
+}
+
+// Fill out any fields left as 0 from DefaultLimits
+func (l Limits) withDefaults() Limits {
+	if l.MaxBodyBytes <= 0 {
+		l.MaxBodyBytes = DefaultLimits.MaxBodyBytes
+	}
+	if l.MaxNotationLength <= 0 {
+		l.MaxNotationLength = DefaultLimits.MaxNotationLength
+	}
+	if l.MaxNodes <= 0 {
+		l.MaxNodes = DefaultLimits.MaxNodes
+	}
+	if l.MaxCost <= 0 {
+		l.MaxCost = DefaultLimits.MaxCost
+	}
+	if l.MaxTimes <= 0 {
+		l.MaxTimes = DefaultLimits.MaxTimes
+	}
+	if l.MaxN <= 0 {
+		l.MaxN = DefaultLimits.MaxN
+	}
+	if l.DefaultN <= 0 {
+		l.DefaultN = min(DefaultLimits.DefaultN, l.MaxN)
+	}
+	if l.MaxWork <= 0 {
+		l.MaxWork = DefaultLimits.MaxWork
+	}
+	if l.MaxThreads <= 0 {
+		l.MaxThreads = DefaultLimits.MaxThreads
+	}
+	if l.Timeout <= 0 {
+		l.Timeout = DefaultLimits.Timeout
+	}
+	return l
+}
+
+// Error for requests that go over one of the Limits.
+var ErrLimit = errors.New("request exceeds limit")
+
+// Create a new http.Handler serving /roll and /analyze.
+func NewHandler(limits Limits) http.Handler {
+	h := &handler{limits: limits.withDefaults()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/roll", h.roll)
+	mux.HandleFunc("/analyze", h.analyze)
+	return mux
+}
+
+type handler struct {
+	limits Limits
+}
+
+//////////////
+// REQUESTS //
+//////////////
+
+// The fields shared by every request.
+type DefinitionRequest struct {
+
+	// The definition to roll. Exactly one of Definition and Notation must be
+	// set.
+	Definition *core.Definition `json:"definition,omitempty"`
+	Notation   string           `json:"notation,omitempty"`
+
+	// Optional seed for reproducible results.
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// The body of POST /roll.
+type RollRequest struct {
+	DefinitionRequest
+
+	// The number of times to roll. Defaults to 1.
+	Times int `json:"times,omitempty"`
+}
+
+// The response to POST /roll.
+type RollResponse struct {
+	Results []*core.Result `json:"results"`
+}
+
+// The body of POST /analyze.
+type AnalyzeRequest struct {
+	DefinitionRequest
+
+	// The number of rolls to make. Defaults to "Limits.DefaultN".
+	N int `json:"n,omitempty"`
+
+	// The number of goroutines to roll in. Defaults to 1.
+	Threads int `json:"threads,omitempty"`
+}
+
+// The response to any request that fails.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// An error along with the status to respond with
+type requestError struct {
+	status int
+	err    error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+func badRequest(err error) error {
+	return &requestError{status: http.StatusBadRequest, err: err}
+}
+
+func limitf(format string, args ...interface{}) error {
+	return &requestError{status: http.StatusUnprocessableEntity, err: fmt.Errorf("%w: %s", ErrLimit, fmt.Sprintf(format, args...))}
+}
+
+///////////////
+// ENDPOINTS //
+///////////////
+
+func (h *handler) roll(w http.ResponseWriter, r *http.Request) {
+	request := &RollRequest{}
+	if err := h.decode(w, r, request); err != nil {
+		h.error(w, err)
+		return
+	}
+
+	d, cost, err := h.definition(&request.DefinitionRequest)
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	times := request.Times
+	if times == 0 {
+		times = 1
+	}
+	switch {
+	case times < 0:
+		h.error(w, badRequest(fmt.Errorf("times must be positive, got %d", times)))
+		return
+	case times > h.limits.MaxTimes:
+		h.error(w, limitf("times is %d, the limit is %d", times, h.limits.MaxTimes))
+		return
+	case core.MulCost(times, cost) > h.limits.MaxCost:
+		h.error(w, limitf("rolling %d times costs up to %d rolls, the limit is %d", times, core.MulCost(times, cost), h.limits.MaxCost))
+		return
+	}
+
+	response := &RollResponse{Results: make([]*core.Result, times)}
+	for i := range response.Results {
+		result, err := d.RollE()
+		if err != nil {
+			h.error(w, badRequest(err))
+			return
+		}
+		response.Results[i] = result
+	}
+	h.respond(w, http.StatusOK, response)
+}
+
+func (h *handler) analyze(w http.ResponseWriter, r *http.Request) {
+	request := &AnalyzeRequest{}
+	if err := h.decode(w, r, request); err != nil {
+		h.error(w, err)
+		return
+	}
+
+	d, cost, err := h.definition(&request.DefinitionRequest)
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	n := request.N
+	if n == 0 {
+		n = h.limits.DefaultN
+	}
+	threads := max(request.Threads, 1)
+	switch {
+	case n < 0:
+		h.error(w, badRequest(fmt.Errorf("n must be positive, got %d", n)))
+		return
+	case n > h.limits.MaxN:
+		h.error(w, limitf("n is %d, the limit is %d", n, h.limits.MaxN))
+		return
+	case core.MulCost(n, cost) > h.limits.MaxWork:
+		h.error(w, limitf("%d rolls cost up to %d rolls, the limit is %d", n, core.MulCost(n, cost), h.limits.MaxWork))
+		return
+	case threads > h.limits.MaxThreads:
+		h.error(w, limitf("threads is %d, the limit is %d", threads, h.limits.MaxThreads))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.limits.Timeout)
+	defer cancel()
+
+	A, err := d.AnalyzeContext(ctx, core.AnalyzeOptions{N: n, Threads: threads, Seed: request.Seed})
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		h.error(w, &requestError{status: http.StatusServiceUnavailable, err: fmt.Errorf("analysis did not finish within %v", h.limits.Timeout)})
+		return
+	case err != nil:
+		h.error(w, badRequest(err))
+		return
+	}
+	h.respond(w, http.StatusOK, A)
+}
+
+/////////////
+// HELPERS //
+/////////////
+
+// Check the method and decode the JSON body into the request
+func (h *handler) decode(w http.ResponseWriter, r *http.Request, request interface{}) error {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		return &requestError{status: http.StatusMethodNotAllowed, err: fmt.Errorf("method %s not allowed", r.Method)}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.limits.MaxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &requestError{status: http.StatusRequestEntityTooLarge, err: fmt.Errorf("%w: body is larger than %d bytes", ErrLimit, h.limits.MaxBodyBytes)}
+		}
+		return badRequest(fmt.Errorf("invalid request: %w", err))
+	}
+	return nil
+}
+
+/*
+Build the definition for the request and check it against the limits. The
+package's default source isn't safe to share between requests, so seeded
+requests get their own source and the rest roll with CryptoRNG, so players
+can't predict the rolls made for them.
+
+/analyze doesn't use this source. It seeds each thread from "seed" through
+AnalyzeOptions, and from a random master seed when there is none, as an
+analysis only reports statistics and CryptoRNG is far too slow for millions
+of rolls.
+*/
+func (h *handler) definition(request *DefinitionRequest) (*core.Definition, int, error) {
+	var d *core.Definition
+	switch {
+	case request.Definition != nil && request.Notation != "":
+		return nil, 0, badRequest(errors.New("only one of definition and notation can be set"))
+
+	case request.Definition != nil:
+		d = request.Definition
+
+	case len(request.Notation) > h.limits.MaxNotationLength:
+		return nil, 0, limitf("notation is %d bytes, the limit is %d", len(request.Notation), h.limits.MaxNotationLength)
+
+	case request.Notation != "":
+		var err error
+		if d, err = dice.Parse(request.Notation); err != nil {
+			return nil, 0, badRequest(err)
+		}
+
+	default:
+		return nil, 0, badRequest(errors.New("one of definition or notation is required"))
+	}
+
+	if size := d.Size(); size > h.limits.MaxNodes {
+		return nil, 0, limitf("definition has %d nodes, the limit is %d", size, h.limits.MaxNodes)
+	}
+	cost, err := d.Cost()
+	if err != nil {
+		return nil, 0, badRequest(err)
+	}
+	if cost > h.limits.MaxCost {
+		return nil, 0, limitf("definition costs up to %d rolls, the limit is %d", cost, h.limits.MaxCost)
+	}
+
+	if request.Seed != nil {
+		d.SetSeed(*request.Seed)
+	} else {
+		d.SetSource(core.CryptoRNG)
+	}
+	return d, cost, nil
+}
+
+// Write a JSON response
+func (h *handler) respond(w http.ResponseWriter, status int, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(&ErrorResponse{Error: err.Error()})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+// Write an error response, using the status of a *requestError if there is one
+func (h *handler) error(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var requestErr *requestError
+	if errors.As(err, &requestErr) {
+		status = requestErr.status
+	}
+	h.respond(w, status, &ErrorResponse{Error: err.Error()})
+}
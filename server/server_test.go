@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flywingedai/dice/core"
+)
+
+// Post a body to the handler and decode the response into "response"
+func post(t *testing.T, handler http.Handler, path, body string, response interface{}) int {
+	t.Helper()
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if err := json.NewDecoder(recorder.Body).Decode(response); err != nil {
+		t.Fatalf("POST %s %s: decoding the response: %v", path, body, err)
+	}
+	return recorder.Code
+}
+
+func TestRoll(t *testing.T) {
+	handler := NewHandler(DefaultLimits)
+	body := `{"notation": "3d6+2", "times": 5, "seed": 42}`
+
+	first := &RollResponse{}
+	if status := post(t, handler, "/roll", body, first); status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+	if len(first.Results) != 5 {
+		t.Fatalf("got %d results, want 5", len(first.Results))
+	}
+	for _, result := range first.Results {
+		if result.Total < 5 || result.Total > 20 {
+			t.Errorf("total %d is out of range for 3d6+2", result.Total)
+		}
+	}
+
+	// The same seed rolls the same results
+	second := &RollResponse{}
+	post(t, handler, "/roll", body, second)
+	a, _ := json.Marshal(first)
+	b, _ := json.Marshal(second)
+	if !bytes.Equal(a, b) {
+		t.Errorf("seeded rolls differ\n%s\n%s", a, b)
+	}
+}
+
+func TestRollDefinition(t *testing.T) {
+	body := `{"definition": {"rollType": "sides", "rollParams": {"sides": 6}}, "seed": 1}`
+	response := &RollResponse{}
+	if status := post(t, NewHandler(DefaultLimits), "/roll", body, response); status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+	if len(response.Results) != 1 || response.Results[0].Total < 1 || response.Results[0].Total > 6 {
+		t.Errorf("unexpected results %+v", response.Results)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+
+	// DefaultLimits allows one thread per CPU, which may be only one
+	handler := NewHandler(Limits{MaxThreads: 2})
+	body := `{"notation": "2d6", "n": 10000, "threads": 2, "seed": 42}`
+
+	first := &core.Analysis{}
+	if status := post(t, handler, "/analyze", body, first); status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+	if first.N != 10000 {
+		t.Errorf("N = %d, want 10000", first.N)
+	}
+	if first.Mean < 6.8 || first.Mean > 7.2 {
+		t.Errorf("mean = %v, want about 7", first.Mean)
+	}
+
+	// The same seed and threads make the same rolls
+	second := &core.Analysis{}
+	post(t, handler, "/analyze", body, second)
+	if first.Mean != second.Mean || first.Deviation != second.Deviation {
+		t.Errorf("seeded analyses differ: %v and %v", first.Mean, second.Mean)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	limits := Limits{
+		MaxNotationLength: 20,
+		MaxNodes:          5,
+		MaxCost:           100,
+		MaxTimes:          10,
+		MaxN:              1000,
+		MaxThreads:        2,
+	}
+	tests := []struct {
+		name   string
+		limits Limits
+		path   string
+		body   string
+		status int
+	}{
+		{"notation length", limits, "/roll", `{"notation": "1+1+1+1+1+1+1+1+1+1+1"}`, http.StatusUnprocessableEntity},
+		{"nodes", limits, "/roll", `{"notation": "1d4+1d6+1d8+1d10+1d12"}`, http.StatusUnprocessableEntity},
+		{"cost", limits, "/roll", `{"notation": "101d6"}`, http.StatusUnprocessableEntity},
+		{"times", limits, "/roll", `{"notation": "1d6", "times": 11}`, http.StatusUnprocessableEntity},
+		{"times cost", limits, "/roll", `{"notation": "20d6", "times": 10}`, http.StatusUnprocessableEntity},
+		{"n", limits, "/analyze", `{"notation": "1d6", "n": 1001}`, http.StatusUnprocessableEntity},
+		{"threads", limits, "/analyze", `{"notation": "1d6", "n": 100, "threads": 3}`, http.StatusUnprocessableEntity},
+		{"timeout", Limits{Timeout: time.Nanosecond}, "/analyze", `{"notation": "1d6", "n": 10000000}`, http.StatusServiceUnavailable},
+		{"body size", Limits{MaxBodyBytes: 10}, "/roll", `{"notation": "1d6"}`, http.StatusRequestEntityTooLarge},
+
+		{"unknown field", limits, "/roll", `{"notation": "1d6", "tims": 2}`, http.StatusBadRequest},
+		{"unknown definition field", limits, "/roll", `{"definition": {"rollType": "sides", "rollParams": {"sides": 6}, "sides": 6}}`, http.StatusBadRequest},
+		{"malformed notation", limits, "/roll", `{"notation": "1d6+"}`, http.StatusBadRequest},
+		{"malformed body", limits, "/roll", `{"notation": `, http.StatusBadRequest},
+		{"no definition", limits, "/analyze", `{}`, http.StatusBadRequest},
+		{"both definitions", limits, "/roll", `{"notation": "1d6", "definition": {"rollType": "sides", "rollParams": {"sides": 6}}}`, http.StatusBadRequest},
+		{"negative times", limits, "/roll", `{"notation": "1d6", "times": -1}`, http.StatusBadRequest},
+		{"negative n", limits, "/analyze", `{"notation": "1d6", "n": -1}`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		response := &ErrorResponse{}
+		if status := post(t, NewHandler(test.limits), test.path, test.body, response); status != test.status {
+			t.Errorf("%s: status = %d, want %d (%s)", test.name, status, test.status, response.Error)
+		}
+		if response.Error == "" {
+			t.Errorf("%s: expected an error message", test.name)
+		}
+	}
+}
+
+func TestMethod(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewHandler(DefaultLimits).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/roll", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusMethodNotAllowed)
+	}
+	if allow := recorder.Header().Get("Allow"); allow != http.MethodPost {
+		t.Errorf("Allow = %q, want %q", allow, http.MethodPost)
+	}
+}